@@ -0,0 +1,45 @@
+package gget
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/dpb587/gget/pkg/cli/opt"
+	"github.com/pkg/errors"
+)
+
+type CachePruneCommand struct {
+	*Runtime  `group:"Runtime Options"`
+	MaxSize   opt.ByteSize  `long:"max-size" description:"remove least-recently used files until the cache is no larger than a size (e.g. 1G)" value-name:"SIZE"`
+	OlderThan time.Duration `long:"older-than" description:"remove files and API responses which have not been used within a duration (e.g. 720h)" value-name:"DURATION"`
+}
+
+func (c *CachePruneCommand) Execute(_ []string) error {
+	if c.OlderThan == 0 && c.MaxSize == 0 {
+		return fmt.Errorf("missing option: --max-size or --older-than")
+	}
+
+	downloadCache, err := c.Runtime.Cache()
+	if err != nil {
+		return errors.Wrap(err, "getting cache")
+	}
+
+	res, err := downloadCache.Prune(int64(c.MaxSize), c.OlderThan)
+	if err != nil {
+		return errors.Wrap(err, "pruning cache")
+	}
+
+	if !c.Runtime.Quiet {
+		ls := ""
+
+		if res.Removed != 1 {
+			ls = "s"
+		}
+
+		fmt.Fprintf(os.Stderr, "Removed %d file%s (%s) from %s\n", res.Removed, ls, bytefmt.ByteSize(uint64(res.Freed)), downloadCache.Dir())
+	}
+
+	return nil
+}
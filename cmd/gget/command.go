@@ -5,21 +5,33 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"strings"
 
 	"code.cloudfoundry.org/bytefmt"
+	"github.com/dpb587/gget/pkg/archive"
+	"github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/checksum"
 	"github.com/dpb587/gget/pkg/cli/opt"
 	"github.com/dpb587/gget/pkg/export"
+	"github.com/dpb587/gget/pkg/lock"
+	"github.com/dpb587/gget/pkg/mirror"
+	"github.com/dpb587/gget/pkg/platform"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/service/github"
 	"github.com/dpb587/gget/pkg/service/gitlab"
 	"github.com/dpb587/gget/pkg/transfer"
 	"github.com/dpb587/gget/pkg/transfer/transferutil"
+	"github.com/dpb587/gget/pkg/treearchive"
 	"github.com/pkg/errors"
 )
 
 type RepositoryOptions struct {
+	Manifest     string             `long:"manifest" description:"download the repositories and resources of a manifest file instead of arguments (e.g. gget.yml)" value-name:"PATH"`
 	RefStability []string           `long:"ref-stability" description:"acceptable stability level(s) for latest (values: stable, pre-release, any) (default: stable)" value-name:"STABILITY"`
 	RefVersions  opt.ConstraintList `long:"ref-version" description:"version constraint(s) to require of latest (e.g. 4.x)" value-name:"CONSTRAINT"`
 	Service      string             `long:"service" description:"specific git service to use (values: github, gitlab) (default: auto-detect)" value-name:"NAME"`
@@ -29,8 +41,13 @@ type RepositoryOptions struct {
 }
 
 type ResourceOptions struct {
+	ArchiveLocal  bool                    `long:"archive-local" description:"build archives (tar, tar.gz, zip) from the repository tree, including submodules, instead of downloading them from the server"`
+	ArchivePath   string                  `long:"archive-path" description:"restrict archives to a directory of the repository (implies --archive-local)" value-name:"DIR"`
+	Arch          string                  `long:"arch" description:"architecture to match with --auto-platform (e.g. amd64, arm64) (default: current architecture)" value-name:"ARCH"`
+	AutoPlatform  bool                    `long:"auto-platform" description:"download only the resource which best matches the operating system and architecture (per resource glob)"`
 	Exclude       opt.ResourceMatcherList `long:"exclude" description:"exclude resource(s) from download (multiple)" value-name:"RESOURCE-GLOB"`
 	IgnoreMissing opt.ResourceMatcherList `long:"ignore-missing" description:"if a resource is not found, skip it rather than failing (multiple)" value-name:"[RESOURCE-GLOB]" optional:"true" optional-value:"*"`
+	OS            string                  `long:"os" description:"operating system to match with --auto-platform (e.g. linux, darwin, windows) (default: current operating system)" value-name:"OS"`
 	Type          service.ResourceType    `long:"type" description:"type of resource to get (values: asset, archive, blob)" default:"asset" value-name:"TYPE"`
 	List          bool                    `long:"list" description:"list matching resources and stop before downloading"`
 
@@ -39,15 +56,32 @@ type ResourceOptions struct {
 }
 
 type DownloadOptions struct {
-	CD             string                  `long:"cd" description:"change to directory before writing files" value-name:"DIR"`
-	Executable     opt.ResourceMatcherList `long:"executable" description:"apply executable permissions to downloads (multiple)" value-name:"[RESOURCE-GLOB]" optional:"true" optional-value:"*"`
-	Export         *opt.Export             `long:"export" description:"export details about the download profile (values: json, jsonpath=TEMPLATE, plain, yaml)" value-name:"FORMAT"`
-	FailFast       bool                    `long:"fail-fast" description:"fail and exit immediately if a download fails"`
-	NoDownload     bool                    `long:"no-download" description:"do not perform any downloads"`
-	NoProgress     bool                    `long:"no-progress" description:"do not show live-updating progress during downloads"`
-	Parallel       int                     `long:"parallel" description:"maximum number of parallel downloads" default:"3" value-name:"NUM"`
-	Stdout         bool                    `long:"stdout" description:"write file contents to stdout rather than disk"`
-	VerifyChecksum opt.VerifyChecksum      `long:"verify-checksum" description:"strategy for verifying checksums (values: auto, required, none, {algo}, {algo}-min)" value-name:"[METHOD]" default:"auto" optional-value:"required"`
+	Atomic          bool                    `long:"atomic" description:"only change local files if every download succeeds (temporary files are removed otherwise)"`
+	CacheMode       opt.CacheMode           `long:"cache" description:"use of cached downloads and API responses (values: off, read, write, readwrite)" default:"off" env:"GGET_CACHE" value-name:"MODE"`
+	CD              string                  `long:"cd" description:"change to directory before writing files" value-name:"DIR"`
+	Decompress      opt.ResourceMatcherList `long:"decompress" description:"decompress single-file downloads (gz, xz, bz2, zst) and remove the compression extension (multiple)" value-name:"[RESOURCE-GLOB]" optional:"true" optional-value:"*"`
+	Exec            opt.ExecList            `long:"exec" description:"run a command after downloading matching resources, with GGET_LOCAL_PATH, GGET_RESOURCE_NAME, GGET_REF, and GGET_CHECKSUMS set (multiple)" value-name:"RESOURCE-GLOB=COMMAND"`
+	Executable      opt.ResourceMatcherList `long:"executable" description:"apply executable permissions to downloads (multiple)" value-name:"[RESOURCE-GLOB]" optional:"true" optional-value:"*"`
+	Export          *opt.Export             `long:"export" description:"export details about the download profile (values: json, jsonpath=TEMPLATE, plain, yaml)" value-name:"FORMAT"`
+	Extract         bool                    `long:"extract" description:"extract archives (tar, tar.gz, tar.xz, tar.bz2, tar.zst, zip) into the target directory instead of keeping them"`
+	ExtractPath     []string                `long:"extract-path" description:"extract only archive members matching a path or file name (multiple) (implies --extract)" value-name:"GLOB"`
+	FailFast        bool                    `long:"fail-fast" description:"fail and exit immediately if a download fails"`
+	Force           bool                    `long:"force" description:"download even if a local file is up to date (overrides --skip-existing)"`
+	Frozen          bool                    `long:"frozen" description:"fail if the ref or resources changed since the lock file was written"`
+	LimitRate       opt.ByteSize            `long:"limit-rate" description:"maximum combined download speed, in bytes per second (e.g. 5M)" value-name:"SIZE"`
+	LockFile        string                  `long:"lock-file" description:"record the resolved ref, resources, and verified checksums to a file (or verify them with --frozen)" value-name:"PATH"`
+	Mirror          opt.MirrorList          `long:"mirror" description:"download asset and archive URLs with a prefix from a mirror first, falling back to the origin (multiple) (e.g. github.com=mirror.example.com/github)" env:"GGET_MIRROR" env-delim:"," value-name:"PREFIX=MIRROR"`
+	Mode            opt.FileModeList        `long:"mode" description:"apply permissions to downloads (e.g. *.sh=0755), overriding --executable (multiple)" value-name:"RESOURCE-GLOB=MODE"`
+	NoDownload      bool                    `long:"no-download" description:"do not perform any downloads"`
+	NoProgress      bool                    `long:"no-progress" description:"do not show live-updating progress during downloads"`
+	Parallel        int                     `long:"parallel" description:"maximum number of parallel downloads" default:"3" value-name:"NUM"`
+	PreserveMtime   bool                    `long:"preserve-mtime" description:"set the modification time of downloads to when the remote content changed (upload or commit date)"`
+	Segments        int                     `long:"segments" description:"maximum number of parallel connections for downloading a single large file (if supported by server)" default:"1" value-name:"NUM"`
+	SkipExisting    opt.SkipMode            `long:"skip-existing" description:"skip downloads when the local file is up to date (values: checksum, size, always)" env:"GGET_SKIP_EXISTING" value-name:"[METHOD]" optional:"true" optional-value:"checksum"`
+	Stdout          bool                    `long:"stdout" description:"write file contents to stdout rather than disk"`
+	StripComponents int                     `long:"strip-components" description:"remove leading directories from extracted archive members" value-name:"NUM"`
+	StripPrefix     string                  `long:"strip-prefix" description:"remove a leading directory from resource names when writing local files (e.g. deploy/)" value-name:"DIR"`
+	VerifyChecksum  opt.VerifyChecksum      `long:"verify-checksum" description:"strategy for verifying checksums (values: auto, required, none, {algo}, {algo}-min)" value-name:"[METHOD]" default:"auto" optional-value:"required"`
 }
 
 type Command struct {
@@ -63,11 +97,64 @@ type CommandArgs struct {
 	Resources opt.ResourceTransferList `positional-arg-name:"[LOCAL-PATH=]RESOURCE-GLOB" description:"resource name(s) to download" optional:"true"`
 }
 
+// manifestIgnoredOption returns the name of a repository or resource option which was used, since manifests configure
+// them per repository.
+func (c *Command) manifestIgnoredOption() string {
+	switch {
+	case len(c.RefStability) > 0:
+		return "ref-stability"
+	case len(c.RefVersions) > 0:
+		return "ref-version"
+	case c.Service != "":
+		return "service"
+	case c.ShowRef:
+		return "show-ref"
+	case c.ArchiveLocal:
+		return "archive-local"
+	case c.ArchivePath != "":
+		return "archive-path"
+	case c.Arch != "":
+		return "arch"
+	case c.AutoPlatform:
+		return "auto-platform"
+	case len(c.Exclude) > 0:
+		return "exclude"
+	case len(c.IgnoreMissing) > 0:
+		return "ignore-missing"
+	case c.OS != "":
+		return "os"
+	case c.Type != service.AssetResourceType:
+		return "type"
+	case c.List:
+		return "list"
+	case c.ShowResources:
+		return "show-resources"
+	}
+
+	return ""
+}
+
 func (c *Command) applySettings() {
 	if v := c.Service; v != "" {
 		c.Args.Ref.Service = v
 	}
 
+	if len(c.ExtractPath) > 0 {
+		c.Extract = true
+	}
+
+	if c.ArchivePath != "" {
+		c.ArchiveLocal = true
+	}
+
+	if c.OS == "" {
+		c.OS = runtime.GOOS
+	}
+
+	if c.Arch == "" {
+		c.Arch = runtime.GOARCH
+	}
+
 	if len(c.Args.Resources) == 0 {
 		c.Args.Resources = opt.ResourceTransferList{
 			{
@@ -114,48 +201,192 @@ func (c *Command) applySettings() {
 }
 
 func (c *Command) RefResolver(ref service.Ref) (service.RefResolver, error) {
+	cacheMode := c.cacheMode()
+
+	newHTTPClient := func() *http.Client {
+		return c.Runtime.NewHTTPClient(cacheMode)
+	}
+
 	res := service.NewMultiRefResolver(
 		c.Runtime.Logger(),
-		github.NewService(c.Runtime.Logger(), github.NewClientFactory(c.Runtime.Logger(), c.Runtime.NewHTTPClient)),
-		gitlab.NewService(c.Runtime.Logger(), gitlab.NewClientFactory(c.Runtime.Logger(), c.Runtime.NewHTTPClient)),
+		github.NewService(c.Runtime.Logger(), github.NewClientFactory(c.Runtime.Logger(), newHTTPClient, c.Runtime.NewDownloadHTTPClient)),
+		gitlab.NewService(c.Runtime.Logger(), gitlab.NewClientFactory(c.Runtime.Logger(), newHTTPClient, c.Runtime.NewDownloadHTTPClient)),
 	)
 
 	return res, nil
 }
 
 func (c *Command) Execute(_ []string) error {
+	if c.Manifest != "" {
+		if c.Args.Ref.Repository != "" {
+			return fmt.Errorf("unexpected argument: repository reference cannot be combined with --manifest")
+		} else if name := c.manifestIgnoredOption(); name != "" {
+			return fmt.Errorf("unsupported option: --%s cannot be combined with --manifest (configure it per repository of the manifest)", name)
+		}
+
+		return withContext(c.Runtime, func(ctx context.Context) error {
+			return syncManifest(ctx, c.Runtime, c.DownloadOptions, c.Manifest)
+		})
+	}
+
 	c.applySettings()
 
 	if c.Args.Ref.Repository == "" {
 		return fmt.Errorf("missing argument: repository reference")
 	}
 
-	verifyChecksumProfile, err := c.VerifyChecksum.Profile()
+	return withContext(c.Runtime, c.execute)
+}
+
+func (c *Command) execute(ctx context.Context) error {
+	// output = stderr since everything should be progress reports
+	plan, err := c.plan(ctx, os.Stderr)
 	if err != nil {
-		return errors.Wrap(err, "parsing --verify-checksum") // pseudo-parsing
+		return phaseError(ctx, c.Runtime, "resolving", err)
+	} else if plan == nil {
+		return nil
+	}
+
+	var pbO io.Writer = os.Stderr
+
+	if c.Runtime.Quiet || c.NoProgress {
+		pbO = ioutil.Discard
+	}
+
+	batch := transfer.NewBatch(c.Runtime.Logger(), plan.transfers, c.Parallel, pbO)
+
+	if c.LimitRate > 0 {
+		batch.LimitRate(int64(c.LimitRate))
+	}
+
+	if c.Atomic {
+		err = batch.TransferAtomic(ctx)
+		if err == nil && !c.Runtime.Quiet {
+			ls := ""
+
+			if len(plan.transfers) != 1 {
+				ls = "s"
+			}
+
+			fmt.Fprintf(os.Stderr, "Committed %d file%s\n", len(plan.transfers), ls)
+		}
+	} else {
+		err = batch.Transfer(ctx, c.FailFast)
 	}
 
-	refResolver, err := c.RefResolver(service.Ref(c.Args.Ref))
 	if err != nil {
-		return errors.Wrap(err, "getting ref resolver")
+		return phaseError(ctx, c.Runtime, "downloading", err)
 	}
 
-	ctx := context.Background()
+	return plan.complete(ctx)
+}
 
-	ref, err := refResolver.ResolveRef(ctx, service.LookupRef{
-		Ref:          service.Ref(c.Args.Ref),
-		RefVersions:  c.RefVersions.Constraints(),
-		RefStability: c.RefStability,
-	})
+// downloadPlan is the result of resolving a ref and its resources before anything is downloaded.
+type downloadPlan struct {
+	ref        service.Ref
+	localPaths []string
+	transfers  []*transfer.Transfer
+	complete   func(ctx context.Context) error
+}
+
+// plan resolves the ref and resources and prepares their transfers. Status messages are written to stdout. A nil plan
+// is returned when there is nothing to download.
+func (c *Command) plan(ctx context.Context, stdout io.Writer) (*downloadPlan, error) {
+	verifyChecksumProfile, err := c.VerifyChecksum.Profile()
 	if err != nil {
-		return errors.Wrap(err, "resolving ref")
+		return nil, errors.Wrap(err, "parsing --verify-checksum") // pseudo-parsing
+	}
+
+	if c.ArchiveLocal && c.Type != service.ArchiveResourceType {
+		return nil, fmt.Errorf("invalid option: --archive-local and --archive-path require --type=archive")
+	}
+
+	if skip := c.skipExisting(); c.LockFile != "" && !c.Frozen && (skip == transferutil.SizeSkip || skip == transferutil.AlwaysSkip) {
+		// kept files would be locked without verifying them
+		return nil, fmt.Errorf("invalid option: --lock-file requires --skip-existing=checksum (other methods do not verify existing files)")
+	}
+
+	var locked *export.Data
+
+	if c.Frozen {
+		if c.LockFile == "" {
+			return nil, fmt.Errorf("missing option: --frozen requires --lock-file")
+		}
+
+		locked, err = lock.Read(c.LockFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading lock file")
+		}
+
+		// every locked resource has a checksum
+		verifyChecksumProfile = checksum.VerificationProfile{
+			Required:   true,
+			Acceptable: checksum.AlgorithmsByStrength,
+			Selector:   checksum.StrongestChecksumSelector{},
+		}
+	}
+
+	cacheMode := c.cacheMode()
+
+	var downloadCache *cache.Cache
+
+	if cacheMode != cache.OffMode {
+		downloadCache, err = c.Runtime.Cache()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting cache")
+		}
+	}
+
+	refKey := cache.RefKey(service.Ref(c.Args.Ref), c.Type, c.RefVersions.RawValues(), c.RefStability)
+
+	var ref service.ResolvedRef
+
+	if c.Runtime.Offline {
+		data, found, err := downloadCache.LookupRef(refKey, verifyChecksumProfile)
+		if err != nil {
+			return nil, errors.Wrap(err, "checking cached ref")
+		}
+
+		if !found && c.LockFile != "" {
+			// refs are only cached by downloads with a writable cache, but the lock file also records them
+			if locked == nil {
+				locked, err = c.readOfflineLock()
+				if err != nil {
+					return nil, errors.Wrap(err, "reading lock file")
+				}
+			}
+
+			if locked != nil {
+				data, found = locked, true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("ref not found in cache: %s (offline; requires an earlier download with --cache=write or --cache=readwrite, or a --lock-file)", service.Ref(c.Args.Ref))
+		}
+
+		ref = export.NewResolvedRef(data)
+	} else {
+		refResolver, err := c.RefResolver(service.Ref(c.Args.Ref))
+		if err != nil {
+			return nil, errors.Wrap(err, "getting ref resolver")
+		}
+
+		ref, err = refResolver.ResolveRef(ctx, service.LookupRef{
+			Ref:          service.Ref(c.Args.Ref),
+			RefVersions:  c.RefVersions.Constraints(),
+			RefStability: c.RefStability,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "resolving ref")
+		}
 	}
 
 	{ // TODO(1.x) remove
 		if c.ShowRef {
 			metadata, err := ref.GetMetadata(ctx)
 			if err != nil {
-				return errors.Wrap(err, "getting metadata")
+				return nil, errors.Wrap(err, "getting metadata")
 			}
 
 			for _, metadatum := range metadata {
@@ -169,13 +400,35 @@ func (c *Command) Execute(_ []string) error {
 	for _, userResource := range c.Args.Resources {
 		candidateResources, err := ref.ResolveResource(ctx, c.Type, service.ResourceName(string(userResource.RemoteMatch)))
 		if err != nil {
-			return errors.Wrapf(err, "resolving resource %s", string(userResource.RemoteMatch))
+			return nil, errors.Wrapf(err, "resolving resource %s", string(userResource.RemoteMatch))
 		} else if len(candidateResources) == 0 {
 			if !c.IgnoreMissing.Match(string(userResource.RemoteMatch)).IsEmpty() {
 				continue
 			}
 
-			return fmt.Errorf("no resource matched: %s", userResource.RemoteMatch)
+			return nil, fmt.Errorf("no resource matched: %s", userResource.RemoteMatch)
+		}
+
+		if c.ArchiveLocal && !c.Runtime.Offline {
+			candidateResources, err = c.localArchives(ref, candidateResources)
+			if err != nil {
+				return nil, err
+			} else if len(candidateResources) == 0 {
+				return nil, fmt.Errorf("no resource matched a format which can be built locally (tar, tar.gz, zip): %s", userResource.RemoteMatch)
+			}
+		}
+
+		if c.AutoPlatform {
+			candidateResources, err = c.selectPlatformResource(string(userResource.RemoteMatch), candidateResources)
+			if err != nil {
+				return nil, err
+			} else if len(candidateResources) == 0 {
+				if !c.IgnoreMissing.Match(string(userResource.RemoteMatch)).IsEmpty() {
+					continue
+				}
+
+				return nil, fmt.Errorf("no resource matched platform %s/%s: %s", c.OS, c.Arch, userResource.RemoteMatch)
+			}
 		}
 
 		for _, candidate := range candidateResources {
@@ -188,18 +441,55 @@ func (c *Command) Execute(_ []string) error {
 				panic("TODO should always match by now?")
 			}
 
+			if userResource.LocalName == "" {
+				resolved.LocalName = c.stripPrefix(resolved.LocalName)
+			}
+
 			localPath := resolved.LocalPath()
 
+			if userResource.LocalName == "" && c.decompress(candidate.GetName()) {
+				localPath = filepath.Join(resolved.LocalDir, archive.TrimCompressionExtension(resolved.LocalName, archive.DetectCompression(resolved.LocalName, nil)))
+			}
+
 			if _, found := resourceMap[localPath]; found {
-				return fmt.Errorf("target file already specified: %s", localPath)
+				return nil, fmt.Errorf("target file already specified: %s", localPath)
 			}
 
 			resourceMap[localPath] = candidate
 		}
 	}
 
-	// output = stderr since everything should be progress reports
-	stdout := os.Stderr
+	var mirrored bool
+
+	if len(c.Mirror) > 0 && !c.Runtime.Offline && (c.Type == service.AssetResourceType || c.Type == service.ArchiveResourceType) {
+		rules := c.Mirror.Rules()
+		client := c.Runtime.NewDownloadHTTPClient()
+
+		for localPath, resource := range resourceMap {
+			var mirroredURL string
+
+			if aur, ok := resource.(service.ArchiveURLResolvedResource); ok {
+				// archives are downloaded through the API, but mirrored from their public URL
+				mirroredURL = aur.GetArchiveURL()
+			} else if dur, ok := resource.(service.DownloadURLResolvedResource); ok {
+				mirroredURL = dur.GetDownloadURL()
+			} else {
+				continue
+			}
+
+			urls := rules.URLs(mirroredURL)
+			if len(urls) == 0 {
+				continue
+			}
+
+			resourceMap[localPath] = mirror.NewResource(c.Runtime.Logger(), client, resource, urls)
+			mirrored = true
+		}
+	}
+
+	// the URLs which served mirrored resources are only known after downloading
+	exportAfterDownload := mirrored && !c.NoDownload
+
 	var finalStatus io.Writer
 
 	if !c.Runtime.Quiet {
@@ -233,7 +523,7 @@ func (c *Command) Execute(_ []string) error {
 		fmt.Fprintf(stdout, "Found %d file%s%s from %s\n", l, ls, extra, ref.CanonicalRef())
 
 		if c.NoProgress {
-			finalStatus = stdout
+			finalStatus = os.Stderr
 		}
 	}
 
@@ -250,43 +540,102 @@ func (c *Command) Execute(_ []string) error {
 			fmt.Println(result)
 		}
 
-		return nil
+		return nil, nil
 	}
 
-	if c.Export != nil {
-		var resourcesList []service.ResolvedResource
+	var resourcesList []service.ResolvedResource
 
-		for _, resource := range resourceMap {
-			resourcesList = append(resourcesList, resource)
+	for _, resource := range resourceMap {
+		resourcesList = append(resourcesList, resource)
+	}
+
+	if locked != nil {
+		err = lock.Verify(ctx, locked, ref, resourcesList)
+		if err != nil {
+			return nil, errors.Wrap(err, "verifying lock file")
 		}
+	}
 
+	if c.Export != nil && !exportAfterDownload {
 		exportData := export.NewData(ref.CanonicalRef(), ref.GetMetadata, resourcesList, verifyChecksumProfile)
 
 		err = c.Export.Export(ctx, os.Stdout, exportData)
 		if err != nil {
-			return errors.Wrap(err, "exporting")
+			return nil, errors.Wrap(err, "exporting")
 		}
 	}
 
 	if c.NoDownload {
-		return nil
+		return nil, nil
+	}
+
+	var downloadCacheCommit string
+
+	if downloadCache != nil {
+		metadata, err := ref.GetMetadata(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting metadata")
+		}
+
+		for _, metadatum := range metadata {
+			if metadatum.Name == "commit" {
+				downloadCacheCommit = metadatum.Value
+			}
+		}
 	}
 
 	var transfers []*transfer.Transfer
+	var lockResources []service.ResolvedResource
 
 	for localPath, resource := range resourceMap {
+		var lockedChecksums checksum.ChecksumList
+		var checksumRecorder checksum.WriteableManager
+
+		cacheName := resource.GetName()
+
+		if c.ArchiveLocal {
+			// built archives differ from those of the server
+			cacheName = fmt.Sprintf("%s?local&path=%s", cacheName, c.ArchivePath)
+		}
+
+		if locked != nil {
+			lockedChecksums, err = lock.Checksums(ctx, locked, resource.GetName())
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting locked checksums of %s", resource.GetName())
+			}
+		} else if c.LockFile != "" {
+			lockResource := lock.NewResource(resource)
+			lockResources = append(lockResources, lockResource)
+			checksumRecorder = lockResource.ChecksumRecorder()
+		}
+
 		xfer, err := transferutil.BuildTransfer(
 			ctx,
 			resource,
 			localPath,
 			transferutil.TransferOptions{
-				Executable:           !c.Executable.Match(resource.GetName()).IsEmpty(),
 				ChecksumVerification: verifyChecksumProfile,
 				FinalStatus:          finalStatus,
+				RetryPolicy:          c.Runtime.RetryPolicy(),
+				Segments:             c.Segments,
+				StallTimeout:         c.Runtime.StallTimeout,
+				Cache:                downloadCache,
+				CacheMode:            cacheMode,
+				CacheKey:             cache.ResourceKey(ref.CanonicalRef(), downloadCacheCommit, c.Type, cacheName),
+				CacheRequired:        c.Runtime.Offline,
+				Checksums:            lockedChecksums,
+				ChecksumRecorder:     checksumRecorder,
+				Decompress:           c.decompress(resource.GetName()),
+				Extract:              c.extractor(localPath, resource.GetName()),
+				SkipExisting:         c.skipExisting(),
+				Mode:                 c.mode(resource.GetName()),
+				PreserveModTime:      c.PreserveMtime,
+				Exec:                 c.Exec.Commands(resource.GetName()),
+				Ref:                  ref.CanonicalRef().String(),
 			},
 		)
 		if err != nil {
-			return errors.Wrapf(err, "preparing transfer of %s", resource.GetName())
+			return nil, errors.Wrapf(err, "preparing transfer of %s", resource.GetName())
 		}
 
 		transfers = append(transfers, xfer)
@@ -297,13 +646,205 @@ func (c *Command) Execute(_ []string) error {
 		return transfers[i].GetSubject() < transfers[j].GetSubject()
 	})
 
-	var pbO io.Writer = stdout
+	var localPaths []string
 
-	if c.Runtime.Quiet || c.NoProgress {
-		pbO = ioutil.Discard
+	for localPath := range resourceMap {
+		localPaths = append(localPaths, localPath)
+	}
+
+	complete := func(ctx context.Context) error {
+		if downloadCache != nil && cacheMode.Writable() && !c.Runtime.Offline {
+			err := downloadCache.StoreRef(ctx, refKey, export.NewData(ref.CanonicalRef(), ref.GetMetadata, resourcesList, verifyChecksumProfile))
+			if err != nil {
+				c.Runtime.Logger().Warnf("failed to cache ref for offline use: %s", err)
+			}
+		}
+
+		if c.Export != nil && exportAfterDownload {
+			err := c.Export.Export(ctx, os.Stdout, export.NewData(ref.CanonicalRef(), ref.GetMetadata, resourcesList, verifyChecksumProfile))
+			if err != nil {
+				return errors.Wrap(err, "exporting")
+			}
+		}
+
+		if c.LockFile != "" && locked == nil {
+			err := lock.Write(ctx, c.LockFile, export.NewData(ref.CanonicalRef(), ref.GetMetadata, lockResources, lock.ChecksumVerification))
+			if err != nil {
+				return errors.Wrap(err, "writing lock file")
+			}
+		}
+
+		return nil
+	}
+
+	return &downloadPlan{
+		ref:        ref.CanonicalRef(),
+		localPaths: localPaths,
+		transfers:  transfers,
+		complete:   complete,
+	}, nil
+}
+
+// readOfflineLock reads the lock file, if it exists, for resolving the ref without the network. Nil is returned if
+// the lock file does not exist.
+func (c *Command) readOfflineLock() (*export.Data, error) {
+	if _, err := os.Stat(c.LockFile); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	locked, err := lock.Read(c.LockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	origin, expected := locked.Origin(), service.Ref(c.Args.Ref)
+	if (expected.Server != "" && origin.Server != expected.Server) || origin.Owner != expected.Owner || origin.Repository != expected.Repository {
+		return nil, fmt.Errorf("locked ref is of a different repository: %s", origin)
+	}
+
+	return locked, nil
+}
+
+// cacheMode is the effective use of the cache, which must be readable when offline.
+func (c *Command) cacheMode() cache.Mode {
+	cacheMode := cache.Mode(c.CacheMode)
+
+	if c.Runtime.Offline {
+		// downloads are only possible from the cache
+		switch cacheMode {
+		case cache.OffMode:
+			cacheMode = cache.ReadMode
+		case cache.WriteMode:
+			cacheMode = cache.ReadWriteMode
+		}
+	}
+
+	return cacheMode
+}
+
+// localArchives replaces archives of the server with those built from the tree. Formats which cannot be built are
+// skipped.
+func (c *Command) localArchives(ref service.ResolvedRef, candidates []service.ResolvedResource) ([]service.ResolvedResource, error) {
+	refResolver, err := c.RefResolver(ref.CanonicalRef())
+	if err != nil {
+		return nil, errors.Wrap(err, "getting ref resolver")
+	}
+
+	var res []service.ResolvedResource
+
+	for _, candidate := range candidates {
+		resource, ok := treearchive.NewResource(candidate, refResolver, ref, c.ArchivePath)
+		if !ok {
+			continue
+		}
+
+		res = append(res, resource)
+	}
+
+	return res, nil
+}
+
+// decompress is whether a resource should be decompressed. Archives are extracted instead when both are enabled.
+func (c *Command) decompress(name string) bool {
+	if c.Decompress.Match(name).IsEmpty() {
+		return false
+	} else if c.Extract {
+		if format, _ := archive.DetectFormat(name, nil); format != archive.UnknownFormat {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stripPrefix removes the --strip-prefix directory from a resource name, if it is within it.
+func (c *Command) stripPrefix(name string) string {
+	if c.StripPrefix == "" {
+		return name
+	}
+
+	prefix := strings.TrimSuffix(c.StripPrefix, "/") + "/"
+
+	if !strings.HasPrefix(name, prefix) || name == prefix {
+		return name
+	}
+
+	return strings.TrimPrefix(name, prefix)
+}
+
+// mode is the permissions of a downloaded resource, or 0 to use the umask.
+func (c *Command) mode(name string) os.FileMode {
+	if mode, found := c.Mode.Mode(name); found {
+		return mode
+	} else if !c.Executable.Match(name).IsEmpty() {
+		return 0755
+	}
+
+	return 0
+}
+
+// skipExisting is how an existing local file is compared before it is downloaded again.
+func (c *Command) skipExisting() transferutil.SkipMode {
+	if c.Force {
+		return transferutil.NoSkip
+	}
+
+	return transferutil.SkipMode(c.SkipExisting)
+}
+
+// extractor returns the archive extraction settings for a target, if extraction is enabled.
+func (c *Command) extractor(localPath, name string) *archive.Extractor {
+	if !c.Extract || c.decompress(name) {
+		return nil
+	}
+
+	return &archive.Extractor{
+		Dir:             filepath.Dir(localPath),
+		Paths:           c.ExtractPath,
+		StripComponents: c.StripComponents,
+		Executable: func(path string) bool {
+			return !c.Executable.Match(path).IsEmpty() || !c.Executable.Match(filepath.Base(path)).IsEmpty()
+		},
+	}
+}
+
+// selectPlatformResource returns the candidate which best matches the platform (or none), excluding resources which
+// would otherwise be excluded.
+func (c *Command) selectPlatformResource(match string, candidates []service.ResolvedResource) ([]service.ResolvedResource, error) {
+	p := platform.Platform{OS: c.OS, Arch: c.Arch}
+
+	candidateMap := map[string]service.ResolvedResource{}
+	var names []string
+
+	for _, candidate := range candidates {
+		if !c.Exclude.Match(candidate.GetName()).IsEmpty() {
+			continue
+		}
+
+		candidateMap[candidate.GetName()] = candidate
+		names = append(names, candidate.GetName())
+	}
+
+	rankings := p.RankAll(names)
+
+	for _, ranking := range rankings {
+		c.Runtime.Logger().Infof("auto-platform: %s: %s", match, ranking)
+	}
+
+	best := platform.Best(rankings)
+	if len(best) == 0 {
+		return nil, nil
+	} else if len(best) > 1 {
+		var bestNames []string
+
+		for _, ranking := range best {
+			bestNames = append(bestNames, ranking.Name)
+		}
+
+		return nil, fmt.Errorf("multiple resources matched platform %s equally: %s (use --exclude or a more specific glob)", p, strings.Join(bestNames, ", "))
 	}
 
-	batch := transfer.NewBatch(c.Runtime.Logger(), transfers, c.Parallel, pbO)
+	c.Runtime.Logger().Infof("auto-platform: %s: selected %s", match, best[0].Name)
 
-	return batch.Transfer(ctx, c.FailFast)
+	return []service.ResolvedResource{candidateMap[best[0].Name]}, nil
 }
@@ -0,0 +1,96 @@
+package gget
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// InterruptedError is returned when a command was cancelled by a signal.
+type InterruptedError struct {
+	Signal os.Signal
+}
+
+var _ error = InterruptedError{}
+
+func (e InterruptedError) Error() string {
+	return fmt.Sprintf("cancelled (%s)", e.Signal)
+}
+
+// ExitCode follows the shell convention of 128 plus the signal number.
+func (e InterruptedError) ExitCode() int {
+	if sig, ok := e.Signal.(syscall.Signal); ok {
+		return 128 + int(sig)
+	}
+
+	return 130
+}
+
+// TimeoutError is returned when a command did not finish within --timeout.
+type TimeoutError struct {
+	Phase   string
+	Timeout time.Duration
+}
+
+var _ error = TimeoutError{}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s (while %s)", e.Timeout, e.Phase)
+}
+
+// withContext runs fn with a context which is cancelled by SIGINT or SIGTERM, allowing in-progress work to clean up
+// its temporary files, and by the runtime timeout. A second signal is not intercepted.
+func withContext(runtime *Runtime, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if runtime.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, runtime.Timeout)
+		defer cancel()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var received os.Signal
+	var receivedM sync.Mutex
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+
+			receivedM.Lock()
+			received = sig
+			receivedM.Unlock()
+
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := fn(ctx)
+
+	receivedM.Lock()
+	defer receivedM.Unlock()
+
+	if err != nil && received != nil {
+		return InterruptedError{Signal: received}
+	}
+
+	return err
+}
+
+// phaseError describes a failure caused by the runtime timeout with the phase which was in progress.
+func phaseError(ctx context.Context, runtime *Runtime, phase string, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return TimeoutError{Phase: phase, Timeout: runtime.Timeout}
+	}
+
+	return err
+}
@@ -1,9 +1,128 @@
 package gget
 
-import "github.com/dpb587/gget/pkg/app"
+import (
+	"reflect"
+	"strings"
+
+	"github.com/dpb587/gget/pkg/app"
+	"github.com/jessevdk/go-flags"
+)
 
 func NewCommand(app app.Version) *Command {
 	return &Command{
 		Runtime: NewRuntime(app),
 	}
 }
+
+// NewSubcommandParser supports maintenance commands which are named by the first argument instead of a repository.
+func NewSubcommandParser(runtime *Runtime) *flags.Parser {
+	parser := flags.NewNamedParser("gget", flags.PassDoubleDash)
+
+	cacheCmd, err := parser.AddCommand("cache", "manage cached downloads", "", &struct{}{})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = cacheCmd.AddCommand("prune", "remove cached downloads", "Remove cached downloads which are older or larger than the limits.", &CachePruneCommand{Runtime: runtime})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = parser.AddCommand("install", "install a binary", "Download the binary which best matches the platform into a bin directory and record its version.", &InstallCommand{Runtime: runtime})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = parser.AddCommand("list-installed", "list installed binaries", "List the binaries and versions which were installed.", &ListInstalledCommand{Runtime: runtime})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = parser.AddCommand("upgrade", "upgrade installed binaries", "Install newer versions of installed binaries, if available.", &UpgradeCommand{Runtime: runtime})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = parser.AddCommand("uninstall", "uninstall binaries", "Remove installed binaries.", &UninstallCommand{Runtime: runtime})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = parser.AddCommand("sync", "download resources of a manifest", "Download the repositories and resources of a manifest file as a single batch.", &SyncCommand{Runtime: runtime})
+	if err != nil {
+		panic(err)
+	}
+
+	return parser
+}
+
+// SubcommandArgs finds a maintenance command after any leading options (e.g. gget -v cache prune). The options of the
+// parser are used to know which leading options have a separate value. The returned arguments are for the subcommand
+// parser and have the leading options moved after the command. False is returned if the arguments are not of a command.
+func SubcommandArgs(parser, subparser *flags.Parser, args []string) ([]string, bool) {
+	for argIdx := 0; argIdx < len(args); argIdx++ {
+		arg := args[argIdx]
+
+		if arg == "--" {
+			return nil, false
+		} else if arg == "-" || !strings.HasPrefix(arg, "-") {
+			if subparser.Find(arg) == nil {
+				return nil, false
+			}
+
+			leading := args[0:argIdx]
+			rest := args[argIdx:]
+
+			for restIdx, restArg := range rest {
+				if restArg == "--" {
+					// options after a double dash would become arguments
+					res := append(append(append([]string{}, rest[0:restIdx]...), leading...), rest[restIdx:]...)
+
+					return res, true
+				}
+			}
+
+			return append(append([]string{}, rest...), leading...), true
+		} else if optionHasSeparateValue(parser, arg) {
+			argIdx++
+		}
+	}
+
+	return nil, false
+}
+
+// optionHasSeparateValue is whether the option is followed by its value as the next argument (e.g. --cache-dir DIR).
+func optionHasSeparateValue(parser *flags.Parser, arg string) bool {
+	var option *flags.Option
+
+	if strings.HasPrefix(arg, "--") {
+		if strings.Contains(arg, "=") {
+			return false
+		}
+
+		option = parser.FindOptionByLongName(arg[2:])
+	} else {
+		shorts := []rune(arg[1:])
+
+		for shortIdx, short := range shorts {
+			option = parser.FindOptionByShortName(short)
+			if option == nil || isBoolOption(option) {
+				continue
+			} else if shortIdx < len(shorts)-1 {
+				// the value is the remainder (e.g. -ofile)
+				return false
+			}
+		}
+	}
+
+	return option != nil && !option.OptionalArgument && !isBoolOption(option)
+}
+
+func isBoolOption(option *flags.Option) bool {
+	t := option.Field().Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Bool
+}
@@ -0,0 +1,322 @@
+package gget
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/cli/opt"
+	"github.com/dpb587/gget/pkg/install"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+type InstallOptions struct {
+	BinDir    string `long:"bin-dir" description:"directory for installed binaries (default: ~/.local/bin)" env:"GGET_BIN_DIR" value-name:"DIR"`
+	StateFile string `long:"state-file" description:"file recording installed binaries (default: ~/.local/share/gget/installed.json)" env:"GGET_STATE_FILE" value-name:"PATH"`
+}
+
+func (o *InstallOptions) binDir() (string, error) {
+	if o.BinDir != "" {
+		return homedir.Expand(o.BinDir)
+	}
+
+	return homedir.Expand(filepath.Join("~", ".local", "bin"))
+}
+
+func (o *InstallOptions) database() (*install.Database, error) {
+	path := o.StateFile
+
+	if path == "" {
+		dataDir := os.Getenv("XDG_DATA_HOME")
+		if dataDir == "" {
+			dataDir = filepath.Join("~", ".local", "share")
+		}
+
+		path = filepath.Join(dataDir, "gget", "installed.json")
+	}
+
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "expanding path")
+	}
+
+	db, err := install.OpenDatabase(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path)
+	}
+
+	return db, nil
+}
+
+type InstallCommand struct {
+	*Runtime        `group:"Runtime Options"`
+	*InstallOptions `group:"Install Options"`
+	Bin             string             `long:"bin" description:"name of the installed binary (default: repository name)" value-name:"NAME"`
+	ExtractPath     []string           `long:"extract-path" description:"archive member(s) to install (multiple) (default: binary name)" value-name:"GLOB"`
+	RefStability    []string           `long:"ref-stability" description:"acceptable stability level(s) for latest (values: stable, pre-release, any) (default: stable)" value-name:"STABILITY"`
+	RefVersions     opt.ConstraintList `long:"ref-version" description:"version constraint(s) to require of latest (e.g. 4.x)" value-name:"CONSTRAINT"`
+	VerifyChecksum  opt.VerifyChecksum `long:"verify-checksum" description:"strategy for verifying checksums (values: auto, required, none, {algo}, {algo}-min)" value-name:"[METHOD]" default:"auto" optional-value:"required"`
+	Args            InstallCommandArgs `positional-args:"true"`
+}
+
+type InstallCommandArgs struct {
+	Ref      opt.Ref             `positional-arg-name:"HOST/OWNER/REPOSITORY[@REF]" description:"repository reference"`
+	Resource opt.ResourceMatcher `positional-arg-name:"RESOURCE-GLOB" description:"asset(s) to consider (default: best match for the platform)"`
+}
+
+func (c *InstallCommand) Execute(_ []string) error {
+	if c.Args.Ref.Repository == "" {
+		return fmt.Errorf("missing argument: repository reference")
+	}
+
+	return withContext(c.Runtime, c.execute)
+}
+
+func (c *InstallCommand) execute(ctx context.Context) error {
+	name := c.Bin
+	if name == "" {
+		name = c.Args.Ref.Repository
+	}
+
+	db, err := c.InstallOptions.database()
+	if err != nil {
+		return errors.Wrap(err, "loading installed state")
+	}
+
+	record, _, err := c.InstallOptions.install(ctx, c.Runtime, install.Record{
+		Name:           name,
+		Origin:         service.Ref(c.Args.Ref).String(),
+		RefVersions:    c.RefVersions.RawValues(),
+		RefStability:   c.RefStability,
+		Resource:       string(c.Args.Resource),
+		ExtractPaths:   c.ExtractPath,
+		VerifyChecksum: c.VerifyChecksum,
+	}, false)
+	if err != nil {
+		return errors.Wrapf(err, "installing %s", name)
+	}
+
+	db.Put(record)
+
+	err = db.Save()
+	if err != nil {
+		return errors.Wrap(err, "saving installed state")
+	}
+
+	if !c.Runtime.Quiet {
+		fmt.Fprintf(os.Stderr, "Installed %s (%s) to %s\n", record.Name, record.Ref, record.Path)
+	}
+
+	return nil
+}
+
+// install downloads the best matching asset of the requested origin and places its binary in the bin directory. If
+// onlyChanged is set and the resolved ref is the same as the request, nothing is downloaded and false is returned.
+func (o *InstallOptions) install(ctx context.Context, runtime *Runtime, request install.Record, onlyChanged bool) (install.Record, bool, error) {
+	binDir, err := o.binDir()
+	if err != nil {
+		return request, false, errors.Wrap(err, "expanding bin directory")
+	}
+
+	err = os.MkdirAll(binDir, 0755)
+	if err != nil {
+		return request, false, errors.Wrap(err, "creating bin directory")
+	}
+
+	// staged next to the result so the final rename is atomic
+	stagingDir, err := ioutil.TempDir(binDir, ".gget-install-")
+	if err != nil {
+		return request, false, errors.Wrap(err, "creating staging directory")
+	}
+
+	defer os.RemoveAll(stagingDir)
+
+	cmd, err := newInstallCommand(runtime, request, stagingDir)
+	if err != nil {
+		return request, false, err
+	}
+
+	plan, err := cmd.plan(ctx, ioutil.Discard)
+	if err != nil {
+		return request, false, phaseError(ctx, runtime, "resolving", err)
+	} else if len(plan.transfers) != 1 {
+		return request, false, fmt.Errorf("expected 1 asset, but found %d", len(plan.transfers))
+	}
+
+	if onlyChanged && plan.ref.String() == request.Ref {
+		return request, false, nil
+	}
+
+	var pbO io.Writer = os.Stderr
+
+	if runtime.Quiet {
+		pbO = ioutil.Discard
+	}
+
+	err = transfer.NewBatch(runtime.Logger(), plan.transfers, 1, pbO).Transfer(ctx, true)
+	if err != nil {
+		return request, false, phaseError(ctx, runtime, "downloading", err)
+	}
+
+	stagedPath, err := findStagedBinary(stagingDir)
+	if err != nil {
+		return request, false, err
+	}
+
+	targetPath := filepath.Join(binDir, request.Name)
+
+	if strings.HasSuffix(stagedPath, ".exe") && !strings.HasSuffix(targetPath, ".exe") {
+		targetPath = fmt.Sprintf("%s.exe", targetPath)
+	}
+
+	err = os.Chmod(stagedPath, 0755)
+	if err != nil {
+		return request, false, errors.Wrap(err, "chmod'ing")
+	}
+
+	digest, err := sha256File(stagedPath)
+	if err != nil {
+		return request, false, err
+	}
+
+	err = os.Rename(stagedPath, targetPath)
+	if err != nil {
+		return request, false, errors.Wrap(err, "renaming")
+	}
+
+	record := request
+	record.Path = targetPath
+	record.Ref = plan.ref.String()
+	record.Asset = plan.transfers[0].GetSubject()
+	record.SHA256 = digest
+	record.InstalledAt = time.Now().UTC()
+
+	return record, true, nil
+}
+
+// newInstallCommand prepares the equivalent download of an install request into a directory.
+func newInstallCommand(runtime *Runtime, request install.Record, dir string) (*Command, error) {
+	cmd := &Command{
+		Runtime: runtime,
+		RepositoryOptions: &RepositoryOptions{
+			RefStability: request.RefStability,
+		},
+		ResourceOptions: &ResourceOptions{
+			AutoPlatform: true,
+			Type:         service.AssetResourceType,
+		},
+		DownloadOptions: &DownloadOptions{
+			CacheMode:      opt.CacheMode(cache.OffMode),
+			Decompress:     opt.ResourceMatcherList{"*"},
+			Extract:        true,
+			ExtractPath:    request.ExtractPaths,
+			Parallel:       1,
+			Segments:       1,
+			VerifyChecksum: opt.VerifyChecksum(request.VerifyChecksum),
+		},
+	}
+
+	if len(cmd.ExtractPath) == 0 {
+		cmd.ExtractPath = []string{request.Name, fmt.Sprintf("%s.exe", request.Name)}
+	}
+
+	if len(cmd.VerifyChecksum) == 0 {
+		cmd.VerifyChecksum = opt.VerifyChecksum{"auto"}
+	}
+
+	err := cmd.Args.Ref.UnmarshalFlag(request.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range request.RefVersions {
+		constraint := &opt.Constraint{}
+
+		err = constraint.UnmarshalFlag(value)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.RefVersions = append(cmd.RefVersions, constraint)
+	}
+
+	resource := request.Resource
+	if resource == "" {
+		resource = "*"
+	}
+
+	cmd.Args.Resources = opt.ResourceTransferList{
+		{
+			RemoteMatch: opt.ResourceMatcher(resource),
+			LocalDir:    dir,
+		},
+	}
+
+	cmd.applySettings()
+
+	return cmd, nil
+}
+
+// findStagedBinary expects a single file was downloaded or extracted.
+func findStagedBinary(dir string) (string, error) {
+	var found []string
+
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		found = append(found, path)
+
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "finding binary")
+	}
+
+	if len(found) == 0 {
+		return "", fmt.Errorf("no binary found")
+	} else if len(found) > 1 {
+		var names []string
+
+		for _, path := range found {
+			rel, _ := filepath.Rel(dir, path)
+			names = append(names, rel)
+		}
+
+		return "", fmt.Errorf("multiple binaries found (use --extract-path): %s", strings.Join(names, ", "))
+	}
+
+	return found[0], nil
+}
+
+func sha256File(path string) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	h := sha256.New()
+
+	_, err = io.Copy(h, fh)
+	if err != nil {
+		return "", errors.Wrap(err, "hashing")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
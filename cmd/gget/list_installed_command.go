@@ -0,0 +1,29 @@
+package gget
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+type ListInstalledCommand struct {
+	*Runtime        `group:"Runtime Options"`
+	*InstallOptions `group:"Install Options"`
+}
+
+func (c *ListInstalledCommand) Execute(_ []string) error {
+	db, err := c.InstallOptions.database()
+	if err != nil {
+		return errors.Wrap(err, "loading installed state")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, record := range db.List() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", record.Name, record.Ref, record.Asset, record.Path)
+	}
+
+	return w.Flush()
+}
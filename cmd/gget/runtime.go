@@ -5,22 +5,40 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/dpb587/gget/pkg/app"
+	"github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/cli/opt"
 	"github.com/dpb587/gget/pkg/ggetutil"
+	"github.com/dpb587/gget/pkg/ratelimit"
+	"github.com/dpb587/gget/pkg/retry"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// apiTimeout limits each API request, including reading its response.
+const apiTimeout = 30 * time.Second
+
 type Runtime struct {
-	Help    bool                 `long:"help" short:"h" description:"show documentation of this command"`
-	Quiet   bool                 `long:"quiet" short:"q" description:"suppress runtime status messages"`
-	Verbose []bool               `long:"verbose" short:"v" description:"increase logging verbosity (multiple)"`
-	Version *ggetutil.VersionOpt `long:"version" description:"show version of this command (with optional constraint to validate)" optional:"true" optional-value:"*" value-name:"[CONSTRAINT]"`
-
-	app        app.Version
-	logger     *logrus.Logger
-	httpClient *http.Client
+	CacheDir     string               `long:"cache-dir" description:"directory for cached downloads (default: user cache directory)" env:"GGET_CACHE_DIR" value-name:"DIR"`
+	Help         bool                 `long:"help" short:"h" description:"show documentation of this command"`
+	MaxAge       time.Duration        `long:"max-age" description:"maximum age of cached API responses before revalidating them (e.g. 1h)" value-name:"DURATION"`
+	MaxWait      time.Duration        `long:"max-wait" description:"maximum duration to wait for a server-requested retry delay or rate limit reset (with --rate-limit=wait) before failing" default:"15m" value-name:"DURATION"`
+	Offline      bool                 `long:"offline" description:"only use previously cached refs, API responses, and downloads instead of the network (refs are cached by downloads with --cache=write or --cache=readwrite, or read from --lock-file)"`
+	Quiet        bool                 `long:"quiet" short:"q" description:"suppress runtime status messages"`
+	RateLimit    opt.RateLimit        `long:"rate-limit" description:"behavior when an API rate limit is exceeded (values: fail, wait)" default:"fail" value-name:"MODE"`
+	Retries      int                  `long:"retries" description:"maximum number of retries for failed requests and downloads" default:"3" value-name:"NUM"`
+	StallTimeout time.Duration        `long:"stall-timeout" description:"maximum duration of a download without receiving content before it is retried" default:"1m" value-name:"DURATION"`
+	Timeout      time.Duration        `long:"timeout" description:"maximum duration of the whole command (e.g. 10m) (default: none)" value-name:"DURATION"`
+	Verbose      []bool               `long:"verbose" short:"v" description:"increase logging verbosity (multiple)"`
+	Version      *ggetutil.VersionOpt `long:"version" description:"show version of this command (with optional constraint to validate)" optional:"true" optional-value:"*" value-name:"[CONSTRAINT]"`
+
+	app              app.Version
+	logger           *logrus.Logger
+	httpClient       *http.Client
+	rateLimitTracker *ratelimit.Tracker
 }
 
 func NewRuntime(app app.Version) *Runtime {
@@ -56,10 +74,70 @@ func (r *Runtime) Logger() *logrus.Logger {
 	return r.logger
 }
 
-func (r *Runtime) NewHTTPClient() *http.Client {
+func (r *Runtime) Cache() (*cache.Cache, error) {
+	dir := r.CacheDir
+
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "finding user cache directory")
+		}
+
+		dir = filepath.Join(userDir, "gget")
+	}
+
+	return cache.New(dir), nil
+}
+
+func (r *Runtime) RetryPolicy() retry.Policy {
+	policy := retry.NewPolicy(r.Retries)
+	policy.MaxWait = r.MaxWait
+
+	return policy
+}
+
+// NewHTTPClient returns a client for API requests. Responses are cached according to the cache mode.
+func (r *Runtime) NewHTTPClient(cacheMode cache.Mode) *http.Client {
+	transport := r.newRoundTripper()
+
+	if cacheMode == cache.OffMode {
+		// no cache
+	} else if apiCache, err := r.Cache(); err != nil {
+		r.Logger().Warnf("disabling api response cache: %s", err)
+	} else {
+		transport = apiCache.NewHTTPRoundTripper(r.Logger(), transport, cache.HTTPOptions{
+			Mode:    cacheMode,
+			MaxAge:  r.MaxAge,
+			Offline: r.Offline,
+		})
+	}
+
 	return &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: roundTripLogger{
+		Timeout:   apiTimeout,
+		Transport: transport,
+	}
+}
+
+// NewDownloadHTTPClient is similar to NewHTTPClient, but without an overall timeout since file downloads may be large.
+// Downloads which stop receiving content are instead interrupted by the stall timeout.
+func (r *Runtime) NewDownloadHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: r.newRoundTripper(),
+	}
+}
+
+func (r *Runtime) RateLimitTracker() *ratelimit.Tracker {
+	if r.rateLimitTracker == nil {
+		r.rateLimitTracker = ratelimit.NewTracker(r.Logger(), ratelimit.Mode(r.RateLimit), r.MaxWait)
+	}
+
+	return r.rateLimitTracker
+}
+
+func (r *Runtime) newRoundTripper() http.RoundTripper {
+	return r.RateLimitTracker().NewRoundTripper(retry.NewRoundTripper(
+		r.Logger(),
+		roundTripLogger{
 			l: r.Logger(),
 			rt: &http.Transport{
 				Proxy: http.ProxyFromEnvironment,
@@ -74,7 +152,8 @@ func (r *Runtime) NewHTTPClient() *http.Client {
 			},
 			ua: r.app.Version(),
 		},
-	}
+		r.RetryPolicy(),
+	))
 }
 
 type roundTripLogger struct {
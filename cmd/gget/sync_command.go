@@ -0,0 +1,380 @@
+package gget
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dpb587/gget/pkg/cli/opt"
+	"github.com/dpb587/gget/pkg/manifest"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/tidwall/limiter"
+)
+
+type SyncCommand struct {
+	*Runtime         `group:"Runtime Options"`
+	*DownloadOptions `group:"Download Options"`
+	Manifest         string `long:"manifest" description:"manifest file of repositories and resources to download" default:"gget.yml" value-name:"PATH"`
+}
+
+func (c *SyncCommand) Execute(_ []string) error {
+	return withContext(c.Runtime, func(ctx context.Context) error {
+		return syncManifest(ctx, c.Runtime, c.DownloadOptions, c.Manifest)
+	})
+}
+
+// syncManifest resolves all repositories of a manifest concurrently and then downloads their resources as a single
+// batch. Failures of individual repositories are summarized at the end rather than stopping the others (unless
+// --fail-fast is used).
+func syncManifest(ctx context.Context, runtime *Runtime, downloadOptions *DownloadOptions, path string) error {
+	if downloadOptions.Export != nil {
+		return fmt.Errorf("unsupported option: --export cannot be combined with a manifest")
+	} else if downloadOptions.LockFile != "" || downloadOptions.Frozen {
+		return fmt.Errorf("unsupported option: --lock-file cannot be combined with a manifest")
+	} else if downloadOptions.Stdout {
+		return fmt.Errorf("unsupported option: --stdout cannot be combined with a manifest")
+	}
+
+	m, err := manifest.Read(path)
+	if err != nil {
+		return errors.Wrap(err, "reading manifest")
+	}
+
+	if len(m.Mirrors) > 0 {
+		mirrorOptions := *downloadOptions
+		mirrorOptions.Mirror = append(opt.MirrorList{}, downloadOptions.Mirror...)
+
+		for _, value := range m.Mirrors {
+			var rule opt.Mirror
+
+			err = rule.UnmarshalFlag(value)
+			if err != nil {
+				return errors.Wrap(err, "parsing manifest mirrors")
+			}
+
+			mirrorOptions.Mirror = append(mirrorOptions.Mirror, rule)
+		}
+
+		downloadOptions = &mirrorOptions
+	}
+
+	var commands []*Command
+
+	for repositoryIdx, repository := range m.Repositories {
+		cmd, err := newManifestCommand(runtime, downloadOptions, repository)
+		if err != nil {
+			return errors.Wrapf(err, "parsing manifest repository %d (%s)", repositoryIdx, repository.Ref)
+		}
+
+		commands = append(commands, cmd)
+	}
+
+	// lazily-initialized and shared by all repositories
+	runtime.Logger()
+	runtime.RateLimitTracker()
+
+	plans := make([]*downloadPlan, len(commands))
+	planErrs := make([]error, len(commands))
+	planOutputs := make([]bytes.Buffer, len(commands))
+
+	{ // resolving
+		planLimiter := limiter.New(downloadOptions.Parallel)
+
+		var wg sync.WaitGroup
+
+		for cmdIdx := range commands {
+			wg.Add(1)
+
+			go func(cmdIdx int) {
+				defer wg.Done()
+
+				planLimiter.Begin()
+				defer planLimiter.End()
+
+				plans[cmdIdx], planErrs[cmdIdx] = commands[cmdIdx].plan(ctx, &planOutputs[cmdIdx])
+			}(cmdIdx)
+		}
+
+		wg.Wait()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return phaseError(ctx, runtime, "resolving", err)
+	}
+
+	var failures []string
+	var transfers []*transfer.Transfer
+
+	localPaths := map[string]string{}
+
+	for cmdIdx, cmd := range commands {
+		planOutputs[cmdIdx].WriteTo(os.Stderr)
+
+		subject := service.Ref(cmd.Args.Ref).String()
+
+		if err := planErrs[cmdIdx]; err != nil {
+			runtime.Logger().Warn(errors.Wrapf(err, "resolving %s", subject))
+
+			failures = append(failures, fmt.Sprintf("%s (%s)", subject, err))
+
+			continue
+		} else if plans[cmdIdx] == nil {
+			continue
+		}
+
+		for _, localPath := range plans[cmdIdx].localPaths {
+			if previous, found := localPaths[localPath]; found {
+				return fmt.Errorf("target file already specified: %s (by %s and %s)", localPath, previous, subject)
+			}
+
+			localPaths[localPath] = subject
+		}
+
+		transfers = append(transfers, plans[cmdIdx].transfers...)
+	}
+
+	if len(failures) > 0 && (downloadOptions.FailFast || downloadOptions.Atomic) {
+		return fmt.Errorf("repositories failed: %s", strings.Join(failures, ", "))
+	}
+
+	var pbO io.Writer = os.Stderr
+
+	if runtime.Quiet || downloadOptions.NoProgress {
+		pbO = ioutil.Discard
+	}
+
+	batch := transfer.NewBatch(runtime.Logger(), transfers, downloadOptions.Parallel, pbO)
+
+	if downloadOptions.LimitRate > 0 {
+		batch.LimitRate(int64(downloadOptions.LimitRate))
+	}
+
+	if downloadOptions.Atomic {
+		err := batch.TransferAtomic(ctx)
+		if err != nil {
+			return phaseError(ctx, runtime, "downloading", err)
+		}
+	} else {
+		// failures are summarized per repository below
+		err := batch.Transfer(ctx, downloadOptions.FailFast)
+		if err != nil && ctx.Err() != nil {
+			return phaseError(ctx, runtime, "downloading", err)
+		}
+	}
+
+	failedTransfers := map[*transfer.Transfer]struct{}{}
+
+	for _, xfer := range batch.Failed() {
+		failedTransfers[xfer] = struct{}{}
+	}
+
+	var synced, syncedFiles int
+
+	for _, plan := range plans {
+		if plan == nil {
+			continue
+		}
+
+		var failedSubjects []string
+
+		for _, xfer := range plan.transfers {
+			if _, found := failedTransfers[xfer]; found {
+				failedSubjects = append(failedSubjects, xfer.GetSubject())
+			}
+		}
+
+		if len(failedSubjects) > 0 {
+			failures = append(failures, fmt.Sprintf("%s (transfers failed: %s)", plan.ref, strings.Join(failedSubjects, ", ")))
+
+			continue
+		}
+
+		err = plan.complete(ctx)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%s)", plan.ref, err))
+
+			continue
+		}
+
+		synced++
+		syncedFiles += len(plan.transfers)
+	}
+
+	if !runtime.Quiet && !downloadOptions.NoDownload {
+		ls := ""
+
+		if syncedFiles != 1 {
+			ls = "s"
+		}
+
+		lr := "repositories"
+
+		if len(commands) == 1 {
+			lr = "repository"
+		}
+
+		fmt.Fprintf(os.Stderr, "Synced %d of %d %s (%d file%s)\n", synced, len(commands), lr, syncedFiles, ls)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("repositories failed: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// newManifestCommand converts a manifest repository into the equivalent command, using downloadOptions as defaults.
+func newManifestCommand(runtime *Runtime, downloadOptions *DownloadOptions, repository manifest.Repository) (*Command, error) {
+	cmdDownloadOptions := *downloadOptions
+
+	cmd := &Command{
+		Runtime: runtime,
+		RepositoryOptions: &RepositoryOptions{
+			RefStability: repository.RefStability,
+			Service:      repository.Service,
+		},
+		ResourceOptions: &ResourceOptions{
+			ArchiveLocal: repository.ArchiveLocal,
+			ArchivePath:  repository.ArchivePath,
+			Arch:         repository.Arch,
+			AutoPlatform: repository.AutoPlatform,
+			OS:           repository.OS,
+			Type:         service.AssetResourceType,
+		},
+		DownloadOptions: &cmdDownloadOptions,
+	}
+
+	err := cmd.Args.Ref.UnmarshalFlag(repository.Ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing ref")
+	}
+
+	if repository.Type != "" {
+		cmd.Type = service.ResourceType(repository.Type)
+	}
+
+	for _, value := range repository.RefVersions {
+		constraint := &opt.Constraint{}
+
+		err = constraint.UnmarshalFlag(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing ref_version")
+		}
+
+		cmd.RefVersions = append(cmd.RefVersions, constraint)
+	}
+
+	for _, value := range repository.Resources {
+		var resource opt.ResourceTransfer
+
+		err = resource.UnmarshalFlag(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing resources")
+		}
+
+		cmd.Args.Resources = append(cmd.Args.Resources, resource)
+	}
+
+	cmd.Exclude, err = parseManifestMatchers(nil, repository.Exclude)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing exclude")
+	}
+
+	cmd.IgnoreMissing, err = parseManifestMatchers(nil, repository.IgnoreMissing)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing ignore_missing")
+	}
+
+	cmd.Executable, err = parseManifestMatchers(downloadOptions.Executable, repository.Executable)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing executable")
+	}
+
+	cmd.Decompress, err = parseManifestMatchers(downloadOptions.Decompress, repository.Decompress)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing decompress")
+	}
+
+	cmd.Exec = append(opt.ExecList{}, downloadOptions.Exec...)
+
+	for _, value := range repository.Exec {
+		var exec opt.Exec
+
+		err = exec.UnmarshalFlag(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing exec")
+		}
+
+		cmd.Exec = append(cmd.Exec, exec)
+	}
+
+	cmd.Mode = append(opt.FileModeList{}, downloadOptions.Mode...)
+
+	for _, value := range repository.Mode {
+		var mode opt.FileMode
+
+		err = mode.UnmarshalFlag(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing mode")
+		}
+
+		cmd.Mode = append(cmd.Mode, mode)
+	}
+
+	if repository.PreserveMtime {
+		cmd.PreserveMtime = true
+	}
+
+	if len(repository.VerifyChecksum) > 0 {
+		cmd.VerifyChecksum = opt.VerifyChecksum(repository.VerifyChecksum)
+	}
+
+	if repository.Extract {
+		cmd.Extract = true
+	}
+
+	if len(repository.ExtractPath) > 0 {
+		cmd.ExtractPath = append(append([]string{}, downloadOptions.ExtractPath...), repository.ExtractPath...)
+	}
+
+	if repository.StripComponents > 0 {
+		cmd.StripComponents = repository.StripComponents
+	}
+
+	if repository.StripPrefix != "" {
+		cmd.StripPrefix = repository.StripPrefix
+	}
+
+	_, err = cmd.VerifyChecksum.Profile()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing verify_checksum")
+	}
+
+	cmd.applySettings()
+
+	return cmd, nil
+}
+
+func parseManifestMatchers(defaults opt.ResourceMatcherList, values []string) (opt.ResourceMatcherList, error) {
+	res := append(opt.ResourceMatcherList{}, defaults...)
+
+	for _, value := range values {
+		matcher := opt.ResourceMatcher(value)
+
+		err := matcher.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, matcher)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,57 @@
+package gget
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+type UninstallCommand struct {
+	*Runtime        `group:"Runtime Options"`
+	*InstallOptions `group:"Install Options"`
+	Args            UninstallCommandArgs `positional-args:"true"`
+}
+
+type UninstallCommandArgs struct {
+	Names []string `positional-arg-name:"NAME" description:"installed binary name(s)"`
+}
+
+func (c *UninstallCommand) Execute(_ []string) error {
+	if len(c.Args.Names) == 0 {
+		return fmt.Errorf("missing argument: installed binary name")
+	}
+
+	db, err := c.InstallOptions.database()
+	if err != nil {
+		return errors.Wrap(err, "loading installed state")
+	}
+
+	for _, name := range c.Args.Names {
+		if _, found := db.Get(name); !found {
+			return fmt.Errorf("not installed: %s", name)
+		}
+	}
+
+	for _, name := range c.Args.Names {
+		record, _ := db.Get(name)
+
+		err = os.Remove(record.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "removing %s", record.Path)
+		}
+
+		db.Delete(name)
+
+		err = db.Save()
+		if err != nil {
+			return errors.Wrap(err, "saving installed state")
+		}
+
+		if !c.Runtime.Quiet {
+			fmt.Fprintf(os.Stderr, "Uninstalled %s (%s) from %s\n", record.Name, record.Ref, record.Path)
+		}
+	}
+
+	return nil
+}
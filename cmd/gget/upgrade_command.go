@@ -0,0 +1,87 @@
+package gget
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dpb587/gget/pkg/install"
+	"github.com/pkg/errors"
+)
+
+type UpgradeCommand struct {
+	*Runtime        `group:"Runtime Options"`
+	*InstallOptions `group:"Install Options"`
+	Args            UpgradeCommandArgs `positional-args:"true"`
+}
+
+type UpgradeCommandArgs struct {
+	Names []string `positional-arg-name:"NAME" description:"installed binary name(s) (default: all)"`
+}
+
+func (c *UpgradeCommand) Execute(_ []string) error {
+	return withContext(c.Runtime, c.execute)
+}
+
+func (c *UpgradeCommand) execute(ctx context.Context) error {
+	db, err := c.InstallOptions.database()
+	if err != nil {
+		return errors.Wrap(err, "loading installed state")
+	}
+
+	var records []install.Record
+
+	if len(c.Args.Names) == 0 {
+		records = db.List()
+	} else {
+		for _, name := range c.Args.Names {
+			record, found := db.Get(name)
+			if !found {
+				return fmt.Errorf("not installed: %s", name)
+			}
+
+			records = append(records, record)
+		}
+	}
+
+	var failures []string
+
+	for _, record := range records {
+		upgraded, changed, err := c.InstallOptions.install(ctx, c.Runtime, record, true)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			c.Runtime.Logger().Warn(errors.Wrapf(err, "upgrading %s", record.Name))
+
+			failures = append(failures, fmt.Sprintf("%s (%s)", record.Name, err))
+
+			continue
+		} else if !changed {
+			if !c.Runtime.Quiet {
+				fmt.Fprintf(os.Stderr, "%s is up to date (%s)\n", record.Name, record.Ref)
+			}
+
+			continue
+		}
+
+		db.Put(upgraded)
+
+		err = db.Save()
+		if err != nil {
+			return errors.Wrap(err, "saving installed state")
+		}
+
+		if !c.Runtime.Quiet {
+			fmt.Fprintf(os.Stderr, "Upgraded %s (%s to %s)\n", record.Name, record.Ref, upgraded.Ref)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("upgrades failed: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/dpb587/gget/cmd/gget"
 	"github.com/dpb587/gget/pkg/app"
 	"github.com/dpb587/gget/pkg/service"
-	"github.com/dpb587/gget/pkg/service/github"
 	"github.com/jessevdk/go-flags"
 	"github.com/pkg/errors"
 )
@@ -34,11 +33,40 @@ func main() {
 			panic(err)
 		}
 
+		if interrupted, ok := errors.Cause(err).(gget.InterruptedError); ok {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", parser.Command.Name, interrupted)
+
+			os.Exit(interrupted.ExitCode())
+		}
+
 		fmt.Fprintf(os.Stderr, "%s: error: %s\n", parser.Command.Name, err)
 
 		os.Exit(1)
 	}
 
+	if len(os.Args) > 1 {
+		subparser := gget.NewSubcommandParser(cmd.Runtime)
+
+		if args, ok := gget.SubcommandArgs(parser, subparser, os.Args[1:]); ok {
+			subparser.CommandHandler = func(command flags.Commander, args []string) error {
+				if cmd.Runtime.Help {
+					subparser.WriteHelp(os.Stdout)
+
+					return nil
+				}
+
+				return command.Execute(args)
+			}
+
+			_, err := subparser.ParseArgs(args)
+			if err != nil {
+				fatal(err)
+			}
+
+			return
+		}
+	}
+
 	_, err := parser.Parse()
 	if err != nil {
 		fatal(err)
@@ -65,8 +93,12 @@ func main() {
 					return errors.Wrap(err, "parsing app origin")
 				}
 
-				svc := github.NewService(cmd.Runtime.Logger(), github.NewClientFactory(cmd.Runtime.Logger(), cmd.Runtime.NewHTTPClient))
-				res, err := svc.ResolveRef(context.Background(), service.LookupRef{Ref: ref})
+				refResolver, err := cmd.RefResolver(ref)
+				if err != nil {
+					return errors.Wrap(err, "getting ref resolver")
+				}
+
+				res, err := refResolver.ResolveRef(context.Background(), service.LookupRef{Ref: ref})
 				if err != nil {
 					return errors.Wrap(err, "resolving app origin")
 				}
@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+type Compression string
+
+const (
+	NoCompression    Compression = ""
+	GzipCompression  Compression = "gzip"
+	XZCompression    Compression = "xz"
+	Bzip2Compression Compression = "bzip2"
+	ZstdCompression  Compression = "zstd"
+)
+
+type compressionFormat struct {
+	Compression Compression
+	Magic       []byte
+	Extensions  []string
+}
+
+var compressionFormats = []compressionFormat{
+	{GzipCompression, []byte{0x1f, 0x8b}, []string{".gz"}},
+	{XZCompression, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, []string{".xz"}},
+	{Bzip2Compression, []byte{'B', 'Z', 'h'}, []string{".bz2"}},
+	{ZstdCompression, []byte{0x28, 0xb5, 0x2f, 0xfd}, []string{".zst", ".zstd"}},
+}
+
+// MagicSize is the number of leading bytes needed to detect a compression format.
+const MagicSize = 6
+
+// DetectCompression identifies the compression of a file by its name or, if the name is not conventional, by the leading
+// bytes of its content (which may be nil).
+func DetectCompression(name string, magic []byte) Compression {
+	lower := strings.ToLower(name)
+
+	for _, format := range compressionFormats {
+		for _, ext := range format.Extensions {
+			if strings.HasSuffix(lower, ext) {
+				return format.Compression
+			}
+		}
+	}
+
+	for _, format := range compressionFormats {
+		if bytes.HasPrefix(magic, format.Magic) {
+			return format.Compression
+		}
+	}
+
+	return NoCompression
+}
+
+// TrimCompressionExtension removes a conventional extension of the compression from a file name.
+func TrimCompressionExtension(name string, compression Compression) string {
+	lower := strings.ToLower(name)
+
+	for _, format := range compressionFormats {
+		if format.Compression != compression {
+			continue
+		}
+
+		for _, ext := range format.Extensions {
+			if strings.HasSuffix(lower, ext) {
+				return name[0 : len(name)-len(ext)]
+			}
+		}
+	}
+
+	return name
+}
+
+// NewDecompressor returns a reader of the decompressed content of r.
+func NewDecompressor(compression Compression, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case NoCompression:
+		return ioutil.NopCloser(r), nil
+	case GzipCompression:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading gzip")
+		}
+
+		return gr, nil
+	case XZCompression:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading xz")
+		}
+
+		return ioutil.NopCloser(xr), nil
+	case Bzip2Compression:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case ZstdCompression:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading zstd")
+		}
+
+		return zr.IOReadCloser(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported compression: %s", compression)
+}
@@ -0,0 +1,519 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	UnknownFormat Format = ""
+	TarFormat     Format = "tar"
+	ZipFormat     Format = "zip"
+)
+
+var tarExtensions = map[string]Compression{
+	".tar":     NoCompression,
+	".tar.gz":  GzipCompression,
+	".tgz":     GzipCompression,
+	".tar.xz":  XZCompression,
+	".txz":     XZCompression,
+	".tar.bz2": Bzip2Compression,
+	".tbz":     Bzip2Compression,
+	".tbz2":    Bzip2Compression,
+	".tar.zst": ZstdCompression,
+	".tzst":    ZstdCompression,
+}
+
+// HeaderSize is the number of leading bytes needed to detect an archive format.
+const HeaderSize = 512
+
+// DetectFormat identifies an archive by its name or, if the name is not conventional, by the leading bytes of its
+// content (which may be nil). Compressed content is assumed to be a tar archive.
+func DetectFormat(name string, header []byte) (Format, Compression) {
+	lower := strings.ToLower(name)
+
+	if strings.HasSuffix(lower, ".zip") {
+		return ZipFormat, NoCompression
+	}
+
+	for ext, compression := range tarExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return TarFormat, compression
+		}
+	}
+
+	if bytes.HasPrefix(header, []byte("PK\x03\x04")) {
+		return ZipFormat, NoCompression
+	} else if compression := DetectCompression("", header); compression != NoCompression {
+		return TarFormat, compression
+	} else if len(header) >= 262 && string(header[257:262]) == "ustar" {
+		return TarFormat, NoCompression
+	}
+
+	return UnknownFormat, NoCompression
+}
+
+// Extractor writes the members of an archive into a directory. Members are never written outside of the directory,
+// whether by their path, symlinks in the archive, or symlinks already in the directory.
+type Extractor struct {
+	Dir string
+
+	// Paths are globs of the members to extract, matched against the full path or the base name of a member. All members
+	// are extracted when empty.
+	Paths []string
+
+	// StripComponents removes leading directories from member paths; members without any remaining path are skipped.
+	StripComponents int
+
+	// Executable forces executable permissions for a member (after stripping components), in addition to members which
+	// were already executable.
+	Executable func(path string) bool
+
+	// Rename replaces a member with its temporary file (default: os.Rename), such as to keep a backup of the member.
+	Rename func(tmpPath, target string) error
+}
+
+type member struct {
+	Name     string
+	Mode     os.FileMode
+	Linkname string
+	Hardlink bool
+	Open     func() (io.ReadCloser, error)
+}
+
+// Extract unpacks an archive and returns the paths of the extracted members, relative to Dir.
+func (e Extractor) Extract(archivePath string, format Format, compression Compression) ([]string, error) {
+	for _, glob := range e.Paths {
+		if _, err := path.Match(glob, "test"); err != nil {
+			return nil, errors.Wrapf(err, "parsing path %s", glob)
+		}
+	}
+
+	var res []string
+
+	extract := func(m member) error {
+		extracted, err := e.extractMember(m)
+		if err != nil {
+			return errors.Wrapf(err, "extracting %s", m.Name)
+		} else if extracted != "" {
+			res = append(res, extracted)
+		}
+
+		return nil
+	}
+
+	var err error
+
+	switch format {
+	case TarFormat:
+		err = e.extractTar(archivePath, compression, extract)
+	case ZipFormat:
+		err = e.extractZip(archivePath, extract)
+	default:
+		err = fmt.Errorf("unsupported archive format: %s", archivePath)
+	}
+
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 && len(e.Paths) > 0 {
+		return nil, fmt.Errorf("no archive members matched: %s", strings.Join(e.Paths, ", "))
+	}
+
+	return res, nil
+}
+
+func (e Extractor) extractTar(archivePath string, compression Compression, extract func(member) error) error {
+	fh, err := os.Open(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "opening archive")
+	}
+
+	defer fh.Close()
+
+	dr, err := NewDecompressor(compression, fh)
+	if err != nil {
+		return err
+	}
+
+	defer dr.Close()
+
+	tr := tar.NewReader(dr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "reading tar")
+		}
+
+		m := member{
+			Name:     hdr.Name,
+			Mode:     hdr.FileInfo().Mode(),
+			Linkname: hdr.Linkname,
+			Open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(tr), nil
+			},
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA, tar.TypeSymlink:
+			// supported
+		case tar.TypeLink:
+			m.Hardlink = true
+		default:
+			// directories are created as needed; devices and others are ignored
+			continue
+		}
+
+		err = extract(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e Extractor) extractZip(archivePath string, extract func(member) error) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "opening zip")
+	}
+
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+
+		if mode.IsDir() {
+			continue
+		} else if !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+			continue
+		}
+
+		m := member{
+			Name: f.Name,
+			Mode: mode,
+			Open: f.Open,
+		}
+
+		if mode&os.ModeSymlink != 0 {
+			linkname, err := readZipLinkname(f)
+			if err != nil {
+				return errors.Wrapf(err, "reading %s", f.Name)
+			}
+
+			m.Linkname = linkname
+		}
+
+		err = extract(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readZipLinkname(f *zip.File) (string, error) {
+	fh, err := f.Open()
+	if err != nil {
+		return "", errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	buf, err := ioutil.ReadAll(io.LimitReader(fh, 4096))
+	if err != nil {
+		return "", errors.Wrap(err, "reading")
+	}
+
+	return string(buf), nil
+}
+
+func (e Extractor) extractMember(m member) (string, error) {
+	name, err := cleanMemberPath(m.Name)
+	if err != nil {
+		return "", err
+	} else if !e.match(name) {
+		return "", nil
+	}
+
+	stripped := e.strip(name)
+	if stripped == "" {
+		return "", nil
+	}
+
+	target := filepath.Join(e.Dir, filepath.FromSlash(stripped))
+
+	err = e.prepareParents(stripped)
+	if err != nil {
+		return "", err
+	}
+
+	if m.Hardlink {
+		linkname, err := cleanMemberPath(m.Linkname)
+		if err != nil {
+			return "", errors.Wrap(err, "checking link")
+		}
+
+		linkname = e.strip(linkname)
+		if linkname == "" {
+			return "", fmt.Errorf("unsafe link: %s", m.Linkname)
+		}
+
+		err = e.prepareParents(linkname)
+		if err != nil {
+			return "", errors.Wrap(err, "checking link")
+		}
+
+		err = e.replace(target, func(tmpPath string) error {
+			return os.Link(filepath.Join(e.Dir, filepath.FromSlash(linkname)), tmpPath)
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "linking")
+		}
+	} else if m.Mode&os.ModeSymlink != 0 {
+		err = e.checkLinkname(stripped, m.Linkname)
+		if err != nil {
+			return "", err
+		}
+
+		err = e.replace(target, func(tmpPath string) error {
+			return os.Symlink(m.Linkname, tmpPath)
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "symlinking")
+		}
+	} else {
+		var mode os.FileMode = 0644
+
+		if m.Mode&0111 != 0 || (e.Executable != nil && e.Executable(stripped)) {
+			mode = 0755
+		}
+
+		err = e.replace(target, func(tmpPath string) error {
+			return writeMember(tmpPath, mode, m.Open)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return stripped, nil
+}
+
+func (e Extractor) match(name string) bool {
+	if len(e.Paths) == 0 {
+		return true
+	}
+
+	for _, glob := range e.Paths {
+		if match, _ := path.Match(glob, name); match {
+			return true
+		} else if match, _ := path.Match(glob, path.Base(name)); match {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e Extractor) strip(name string) string {
+	split := strings.Split(name, "/")
+	if len(split) <= e.StripComponents {
+		return ""
+	}
+
+	return strings.Join(split[e.StripComponents:], "/")
+}
+
+// prepareParents creates the parent directories of a member, refusing to follow any existing symlinks.
+func (e Extractor) prepareParents(name string) error {
+	dir := e.Dir
+	split := strings.Split(name, "/")
+
+	for _, component := range split[0 : len(split)-1] {
+		dir = filepath.Join(dir, component)
+
+		fi, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			err = os.Mkdir(dir, 0755)
+			if err != nil {
+				return errors.Wrap(err, "creating directory")
+			}
+
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "checking directory")
+		} else if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("unsafe path: %s (through symlink)", name)
+		} else if !fi.IsDir() {
+			return fmt.Errorf("unsafe path: %s (through file)", name)
+		}
+	}
+
+	return nil
+}
+
+// checkLinkname rejects symlinks which may resolve outside of the directory. Parent references are only allowed at the
+// start of the link since later ones are resolved from whatever the preceding component is on disk, which may be
+// another symlink. Existing symlinks along the link must also resolve within the directory.
+func (e Extractor) checkLinkname(name, linkname string) error {
+	if path.IsAbs(linkname) || strings.Contains(linkname, "\\") {
+		return fmt.Errorf("unsafe symlink: %s", linkname)
+	}
+
+	dir := path.Dir(name)
+	descending := false
+
+	for _, component := range strings.Split(linkname, "/") {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			if descending || dir == "." {
+				return fmt.Errorf("unsafe symlink: %s", linkname)
+			}
+
+			dir = path.Dir(dir)
+
+			continue
+		}
+
+		descending = true
+		dir = path.Join(dir, component)
+
+		err := e.checkWithin(dir)
+		if err != nil {
+			return errors.Wrapf(err, "unsafe symlink: %s", linkname)
+		}
+	}
+
+	return nil
+}
+
+// checkWithin returns an error if the path is an existing symlink which resolves outside of the directory.
+func (e Extractor) checkWithin(name string) error {
+	p := filepath.Join(e.Dir, filepath.FromSlash(name))
+
+	fi, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "checking path")
+	} else if fi.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return errors.Wrapf(err, "resolving %s", name)
+	}
+
+	root, err := filepath.EvalSymlinks(e.Dir)
+	if err != nil {
+		return errors.Wrap(err, "resolving directory")
+	}
+
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return fmt.Errorf("%s resolves outside of the directory", name)
+	}
+
+	return nil
+}
+
+// cleanMemberPath normalizes a member path, rejecting absolute paths and paths outside of the archive root.
+func cleanMemberPath(name string) (string, error) {
+	if strings.Contains(name, "\\") || path.IsAbs(name) {
+		return "", fmt.Errorf("unsafe path: %s", name)
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("unsafe path: %s", name)
+	} else if cleaned == "." {
+		return "", nil
+	}
+
+	return cleaned, nil
+}
+
+// replace creates a temporary file with create and then renames it over target.
+func (e Extractor) replace(target string, create func(tmpPath string) error) error {
+	dir, base := filepath.Split(target)
+	tmpPath := filepath.Join(dir, fmt.Sprintf(".gget-%s.extract", base))
+
+	err := os.Remove(tmpPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing temporary file")
+	}
+
+	err = create(tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return err
+	}
+
+	rename := os.Rename
+	if e.Rename != nil {
+		rename = e.Rename
+	}
+
+	err = rename(tmpPath, target)
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "renaming")
+	}
+
+	return nil
+}
+
+func writeMember(path string, mode os.FileMode, open func() (io.ReadCloser, error)) error {
+	r, err := open()
+	if err != nil {
+		return errors.Wrap(err, "opening member")
+	}
+
+	defer r.Close()
+
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return errors.Wrap(err, "creating file")
+	}
+
+	_, err = io.Copy(fh, r)
+	if err != nil {
+		fh.Close()
+
+		return errors.Wrap(err, "writing file")
+	}
+
+	err = fh.Close()
+	if err != nil {
+		return errors.Wrap(err, "closing file")
+	}
+
+	// ignore umask for consistency with --executable
+	err = os.Chmod(path, mode)
+	if err != nil {
+		return errors.Wrap(err, "chmod'ing")
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/ulikunitz/xz"
+
+	. "github.com/dpb587/gget/pkg/archive"
+)
+
+type testMember struct {
+	name     string
+	mode     int64
+	body     string
+	linkname string
+	typeflag byte
+}
+
+func writeTar(w io.Writer, members []testMember) {
+	tw := tar.NewWriter(w)
+
+	for _, m := range members {
+		typeflag := m.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+
+		mode := m.mode
+		if mode == 0 {
+			mode = 0644
+		}
+
+		Expect(tw.WriteHeader(&tar.Header{
+			Name:     m.name,
+			Mode:     mode,
+			Size:     int64(len(m.body)),
+			Linkname: m.linkname,
+			Typeflag: typeflag,
+		})).To(Succeed())
+
+		_, err := tw.Write([]byte(m.body))
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Expect(tw.Close()).To(Succeed())
+}
+
+var _ = Describe("Extractor", func() {
+	var tmpdir, dir string
+
+	members := []testMember{
+		{name: "tool-1.0.0/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "tool-1.0.0/tool", body: "binary", mode: 0755},
+		{name: "tool-1.0.0/README.md", body: "readme"},
+		{name: "tool-1.0.0/doc/guide.md", body: "guide"},
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-archive-test-")
+		Expect(err).ToNot(HaveOccurred())
+
+		dir = filepath.Join(tmpdir, "out")
+		Expect(os.Mkdir(dir, 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	writeArchive := func(name string, write func(w io.Writer)) string {
+		path := filepath.Join(tmpdir, name)
+
+		fh, err := os.Create(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		write(fh)
+
+		Expect(fh.Close()).To(Succeed())
+
+		return path
+	}
+
+	readFile := func(path string) string {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, path))
+		Expect(err).ToNot(HaveOccurred())
+
+		return string(buf)
+	}
+
+	extract := func(e Extractor, path string) ([]string, error) {
+		e.Dir = dir
+
+		format, compression := DetectFormat(filepath.Base(path), nil)
+
+		return e.Extract(path, format, compression)
+	}
+
+	It("extracts compressed tar archives", func() {
+		for name, compress := range map[string]func(w io.Writer) io.WriteCloser{
+			"tool.tar.gz": func(w io.Writer) io.WriteCloser {
+				return gzip.NewWriter(w)
+			},
+			"tool.tar.xz": func(w io.Writer) io.WriteCloser {
+				xw, err := xz.NewWriter(w)
+				Expect(err).ToNot(HaveOccurred())
+
+				return xw
+			},
+			"tool.tar.zst": func(w io.Writer) io.WriteCloser {
+				zw, err := zstd.NewWriter(w)
+				Expect(err).ToNot(HaveOccurred())
+
+				return zw
+			},
+		} {
+			path := writeArchive(name, func(w io.Writer) {
+				cw := compress(w)
+				writeTar(cw, members)
+				Expect(cw.Close()).To(Succeed())
+			})
+
+			extracted, err := extract(Extractor{}, path)
+			Expect(err).ToNot(HaveOccurred(), name)
+			Expect(extracted).To(Equal([]string{"tool-1.0.0/tool", "tool-1.0.0/README.md", "tool-1.0.0/doc/guide.md"}), name)
+			Expect(readFile("tool-1.0.0/doc/guide.md")).To(Equal("guide"))
+		}
+	})
+
+	It("extracts zip archives", func() {
+		path := writeArchive("tool.zip", func(w io.Writer) {
+			zw := zip.NewWriter(w)
+
+			fh := &zip.FileHeader{Name: "tool", Method: zip.Deflate}
+			fh.SetMode(0755)
+
+			fw, err := zw.CreateHeader(fh)
+			Expect(err).ToNot(HaveOccurred())
+
+			fw.Write([]byte("binary"))
+
+			Expect(zw.Close()).To(Succeed())
+		})
+
+		extracted, err := extract(Extractor{}, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extracted).To(Equal([]string{"tool"}))
+		Expect(readFile("tool")).To(Equal("binary"))
+
+		fi, err := os.Stat(filepath.Join(dir, "tool"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0755)))
+	})
+
+	It("selects and strips members", func() {
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, members)
+		})
+
+		extracted, err := extract(Extractor{Paths: []string{"tool", "*.md"}, StripComponents: 1}, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extracted).To(Equal([]string{"tool", "README.md", "doc/guide.md"}))
+
+		fi, err := os.Stat(filepath.Join(dir, "tool"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+		fi, err = os.Stat(filepath.Join(dir, "README.md"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0644)))
+	})
+
+	It("applies executable permissions per member", func() {
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, members)
+		})
+
+		_, err := extract(Extractor{StripComponents: 1, Executable: func(path string) bool { return path == "README.md" }}, path)
+		Expect(err).ToNot(HaveOccurred())
+
+		fi, err := os.Stat(filepath.Join(dir, "README.md"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0755)))
+	})
+
+	It("fails when no members match", func() {
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, members)
+		})
+
+		_, err := extract(Extractor{Paths: []string{"missing"}}, path)
+		Expect(err).To(MatchError("no archive members matched: missing"))
+	})
+
+	It("rejects path traversal", func() {
+		for _, name := range []string{"../escape", "/etc/escape", "a/../../escape"} {
+			path := writeArchive("tool.tar", func(w io.Writer) {
+				writeTar(w, []testMember{{name: name, body: "escape"}})
+			})
+
+			_, err := extract(Extractor{}, path)
+			Expect(err).To(MatchError(ContainSubstring("unsafe path")), name)
+		}
+
+		_, err := os.Stat(filepath.Join(tmpdir, "escape"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("rejects symlinks outside of the directory", func() {
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, []testMember{{name: "link", linkname: "../outside", typeflag: tar.TypeSymlink}})
+		})
+
+		_, err := extract(Extractor{}, path)
+		Expect(err).To(MatchError(ContainSubstring("unsafe symlink")))
+	})
+
+	It("rejects symlinks outside of the directory through other symlinks", func() {
+		Expect(ioutil.WriteFile(filepath.Join(tmpdir, "secret"), []byte("secret"), 0644)).To(Succeed())
+
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, []testMember{
+				{name: "l", linkname: ".", typeflag: tar.TypeSymlink},
+				{name: "x", linkname: "l/../secret", typeflag: tar.TypeSymlink},
+			})
+		})
+
+		_, err := extract(Extractor{}, path)
+		Expect(err).To(MatchError(ContainSubstring("unsafe symlink")))
+
+		_, err = os.Lstat(filepath.Join(dir, "x"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		Expect(os.Symlink(tmpdir, filepath.Join(dir, "existing"))).To(Succeed())
+
+		path = writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, []testMember{{name: "y", linkname: "existing/secret", typeflag: tar.TypeSymlink}})
+		})
+
+		_, err = extract(Extractor{}, path)
+		Expect(err).To(MatchError(ContainSubstring("unsafe symlink")))
+	})
+
+	It("does not write through symlinks", func() {
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, []testMember{
+				{name: "link", linkname: ".", typeflag: tar.TypeSymlink},
+				{name: "link/file", body: "content"},
+			})
+		})
+
+		_, err := extract(Extractor{}, path)
+		Expect(err).To(MatchError(ContainSubstring("through symlink")))
+
+		Expect(os.Symlink(tmpdir, filepath.Join(dir, "existing"))).To(Succeed())
+
+		path = writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, []testMember{{name: "existing/escape", body: "content"}})
+		})
+
+		_, err = extract(Extractor{}, path)
+		Expect(err).To(MatchError(ContainSubstring("through symlink")))
+
+		_, err = os.Stat(filepath.Join(tmpdir, "escape"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("extracts safe links", func() {
+		path := writeArchive("tool.tar", func(w io.Writer) {
+			writeTar(w, []testMember{
+				{name: "bin/tool", body: "binary", mode: 0755},
+				{name: "tool", linkname: "bin/tool", typeflag: tar.TypeSymlink},
+				{name: "copy", linkname: "bin/tool", typeflag: tar.TypeLink},
+			})
+		})
+
+		_, err := extract(Extractor{}, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile("tool")).To(Equal("binary"))
+		Expect(readFile("copy")).To(Equal("binary"))
+	})
+})
+
+var _ = Describe("DetectFormat", func() {
+	It("detects by name", func() {
+		format, compression := DetectFormat("tool_linux_amd64.tgz", nil)
+		Expect(format).To(Equal(TarFormat))
+		Expect(compression).To(Equal(GzipCompression))
+
+		format, compression = DetectFormat("tool.tar.bz2", nil)
+		Expect(format).To(Equal(TarFormat))
+		Expect(compression).To(Equal(Bzip2Compression))
+
+		format, _ = DetectFormat("tool.ZIP", nil)
+		Expect(format).To(Equal(ZipFormat))
+	})
+
+	It("detects by content", func() {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		gw.Close()
+
+		format, compression := DetectFormat("tool", buf.Bytes())
+		Expect(format).To(Equal(TarFormat))
+		Expect(compression).To(Equal(GzipCompression))
+
+		format, _ = DetectFormat("tool", []byte("binary"))
+		Expect(format).To(Equal(UnknownFormat))
+	})
+})
+
+var _ = Describe("DetectCompression", func() {
+	It("detects by name or content", func() {
+		Expect(DetectCompression("tool-linux-amd64.XZ", nil)).To(Equal(XZCompression))
+		Expect(DetectCompression("tool", []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00})).To(Equal(ZstdCompression))
+		Expect(DetectCompression("tool", []byte("BZh91AY"))).To(Equal(Bzip2Compression))
+		Expect(DetectCompression("tool", []byte("binary"))).To(Equal(NoCompression))
+	})
+
+	It("removes the extension", func() {
+		Expect(TrimCompressionExtension("tool-linux-amd64.gz", GzipCompression)).To(Equal("tool-linux-amd64"))
+		Expect(TrimCompressionExtension("tool.ZST", ZstdCompression)).To(Equal("tool"))
+		Expect(TrimCompressionExtension("tool", GzipCompression)).To(Equal("tool"))
+	})
+})
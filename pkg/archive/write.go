@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Entry is a file to be written into an archive.
+type Entry struct {
+	Name string
+
+	// Mode includes os.ModeSymlink for symlinks, in which case Linkname is used rather than content.
+	Mode     os.FileMode
+	Linkname string
+
+	// Size must be the exact size of the content.
+	Size    int64
+	ModTime time.Time
+	Open    func() (io.ReadCloser, error)
+}
+
+// Write creates an archive of the entries. Only gzip compression is supported for tar archives; zip archives use
+// their own compression.
+func Write(w io.Writer, format Format, compression Compression, entries []Entry) error {
+	switch format {
+	case TarFormat:
+		switch compression {
+		case NoCompression:
+			return writeTar(w, entries)
+		case GzipCompression:
+			gw := gzip.NewWriter(w)
+
+			err := writeTar(gw, entries)
+			if err != nil {
+				return err
+			}
+
+			return gw.Close()
+		}
+	case ZipFormat:
+		if compression == NoCompression {
+			return writeZip(w, entries)
+		}
+	}
+
+	return fmt.Errorf("unsupported archive format for writing: %s (compression: %s)", format, compression)
+}
+
+func writeTar(w io.Writer, entries []Entry) error {
+	tw := tar.NewWriter(w)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:    entry.Name,
+			Mode:    int64(entry.Mode.Perm()),
+			ModTime: entry.ModTime,
+		}
+
+		if entry.Mode&os.ModeSymlink != 0 {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.Linkname
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = entry.Size
+		}
+
+		err := tw.WriteHeader(header)
+		if err != nil {
+			return errors.Wrapf(err, "writing header of %s", entry.Name)
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			err = copyEntry(tw, entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, entries []Entry) error {
+	zw := zip.NewWriter(w)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.Name,
+			Method:   zip.Deflate,
+			Modified: entry.ModTime,
+		}
+
+		header.SetMode(entry.Mode)
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return errors.Wrapf(err, "writing header of %s", entry.Name)
+		}
+
+		if entry.Mode&os.ModeSymlink != 0 {
+			_, err = fw.Write([]byte(entry.Linkname))
+			if err != nil {
+				return errors.Wrapf(err, "writing %s", entry.Name)
+			}
+
+			continue
+		}
+
+		err = copyEntry(fw, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func copyEntry(w io.Writer, entry Entry) error {
+	fh, err := entry.Open()
+	if err != nil {
+		return errors.Wrapf(err, "opening %s", entry.Name)
+	}
+
+	defer fh.Close()
+
+	_, err = io.Copy(w, fh)
+	if err != nil {
+		return errors.Wrapf(err, "writing %s", entry.Name)
+	}
+
+	return nil
+}
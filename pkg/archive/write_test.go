@@ -0,0 +1,89 @@
+package archive_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/archive"
+)
+
+var _ = Describe("Write", func() {
+	var tmpdir string
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-archive-test-")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	entry := func(name string, mode os.FileMode, body string) Entry {
+		return Entry{
+			Name: name,
+			Mode: mode,
+			Size: int64(len(body)),
+			Open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader(body)), nil
+			},
+		}
+	}
+
+	entries := []Entry{
+		entry("tool-1.0.0/tool", 0755, "binary"),
+		entry("tool-1.0.0/doc/guide.md", 0644, "guide"),
+		{Name: "tool-1.0.0/README.md", Mode: os.ModeSymlink | 0777, Linkname: "doc/guide.md"},
+	}
+
+	It("writes archives which can be extracted", func() {
+		for _, name := range []string{"tool.tar", "tool.tar.gz", "tool.zip"} {
+			archivePath := filepath.Join(tmpdir, name)
+
+			fh, err := os.Create(archivePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			format, compression := DetectFormat(name, nil)
+			Expect(Write(fh, format, compression, entries)).To(Succeed(), name)
+			Expect(fh.Close()).To(Succeed())
+
+			dir := filepath.Join(tmpdir, name+"-out")
+			Expect(os.Mkdir(dir, 0755)).To(Succeed())
+
+			extracted, err := Extractor{Dir: dir, StripComponents: 1}.Extract(archivePath, format, compression)
+			Expect(err).ToNot(HaveOccurred(), name)
+			Expect(extracted).To(ConsistOf("tool", "doc/guide.md", "README.md"), name)
+
+			buf, err := ioutil.ReadFile(filepath.Join(dir, "README.md"))
+			Expect(err).ToNot(HaveOccurred(), name)
+			Expect(string(buf)).To(Equal("guide"))
+
+			fi, err := os.Stat(filepath.Join(dir, "tool"))
+			Expect(err).ToNot(HaveOccurred(), name)
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0755)), name)
+		}
+	})
+
+	It("fails for unsupported compression", func() {
+		err := Write(ioutil.Discard, TarFormat, XZCompression, entries)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unsupported archive format"))
+	})
+
+	It("fails when a size is wrong", func() {
+		broken := entry("tool", 0755, "binary")
+		broken.Size = 2
+
+		err := Write(ioutil.Discard, TarFormat, NoCompression, []Entry{broken})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("writing tool"))
+	})
+})
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dpb587/gget/pkg/fsutil"
+	"github.com/pkg/errors"
+)
+
+// Cache stores downloaded files by the sha256 of their content. An index of keys (such as resource names and verified
+// checksums) refers to the stored files. Files are copied into and out of the cache and stored read-only, so later
+// changes to downloaded files (e.g. their mode or modification time) never affect the cache.
+type Cache struct {
+	dir string
+}
+
+type Entry struct {
+	Path   string
+	Size   int64
+	SHA256 []byte
+}
+
+type indexRecord struct {
+	Key    string `json:"key"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+func New(dir string) *Cache {
+	return &Cache{
+		dir: dir,
+	}
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Lookup returns the entry of the first key found in the index.
+func (c *Cache) Lookup(keys ...string) (*Entry, bool, error) {
+	for _, key := range keys {
+		indexPath := c.indexPath(key)
+
+		record, err := readIndexRecord(indexPath)
+		if err != nil {
+			if os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
+
+			return nil, false, err
+		} else if record.Key != key {
+			continue
+		}
+
+		digest, err := hex.DecodeString(record.SHA256)
+		if err != nil || len(digest) != sha256.Size {
+			continue
+		}
+
+		blobPath := c.blobPath(record.SHA256)
+
+		fi, err := os.Stat(blobPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, false, errors.Wrap(err, "checking file")
+		} else if fi.Size() != record.Size {
+			// modified since it was stored
+			os.Remove(blobPath)
+
+			continue
+		}
+
+		// index modification times track usage for pruning
+		now := time.Now()
+		os.Chtimes(indexPath, now, now)
+
+		return &Entry{
+			Path:   blobPath,
+			Size:   record.Size,
+			SHA256: digest,
+		}, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// Store adds the file at path with its known sha256 digest and indexes it by the keys.
+func (c *Cache) Store(path string, digest []byte, keys ...string) (*Entry, error) {
+	digestHex := hex.EncodeToString(digest)
+	blobPath := c.blobPath(digestHex)
+
+	fi, err := os.Stat(blobPath)
+	if os.IsNotExist(err) {
+		err = os.MkdirAll(filepath.Dir(blobPath), 0755)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating directory")
+		}
+
+		tmpPath := fmt.Sprintf("%s.%d.tmp", blobPath, os.Getpid())
+		os.Remove(tmpPath)
+
+		err = fsutil.Copy(path, tmpPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "copying file")
+		}
+
+		err = os.Chmod(tmpPath, 0444)
+		if err != nil {
+			os.Remove(tmpPath)
+
+			return nil, errors.Wrap(err, "protecting file")
+		}
+
+		err = os.Rename(tmpPath, blobPath)
+		if err != nil {
+			os.Remove(tmpPath)
+
+			return nil, errors.Wrap(err, "renaming file")
+		}
+
+		fi, err = os.Stat(blobPath)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "checking file")
+	}
+
+	for _, key := range keys {
+		err = writeIndexRecord(c.indexPath(key), indexRecord{
+			Key:    key,
+			SHA256: digestHex,
+			Size:   fi.Size(),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "indexing %s", key)
+		}
+	}
+
+	return &Entry{
+		Path:   blobPath,
+		Size:   fi.Size(),
+		SHA256: digest,
+	}, nil
+}
+
+func (c *Cache) blobPath(digestHex string) string {
+	return filepath.Join(c.dir, "blobs", "sha256", digestHex[0:2], digestHex)
+}
+
+func (c *Cache) indexPath(key string) string {
+	keyDigest := sha256.Sum256([]byte(key))
+	keyHex := hex.EncodeToString(keyDigest[:])
+
+	return filepath.Join(c.dir, "index", keyHex[0:2], keyHex)
+}
+
+func readIndexRecord(path string) (indexRecord, error) {
+	var record indexRecord
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return record, errors.Wrap(err, "reading index")
+	}
+
+	err = json.Unmarshal(buf, &record)
+	if err != nil {
+		return record, errors.Wrap(err, "parsing index")
+	}
+
+	return record, nil
+}
+
+func writeIndexRecord(path string, record indexRecord) error {
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "marshaling")
+	}
+
+	return writeFile(path, buf)
+}
+
+func writeFile(path string, buf []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	tmpPath := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+
+	err = ioutil.WriteFile(tmpPath, buf, 0644)
+	if err != nil {
+		return errors.Wrap(err, "writing")
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "renaming")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package cache_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vbauerster/mpb/v4"
+
+	. "github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/retry"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/dpb587/gget/pkg/transfer/step"
+)
+
+var _ = Describe("Cache", func() {
+	var tmpdir string
+	var subject *Cache
+
+	store := func(name, content string, keys ...string) *Entry {
+		path := filepath.Join(tmpdir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+
+		digest := sha256.Sum256([]byte(content))
+
+		entry, err := subject.Store(path, digest[:], keys...)
+		Expect(err).ToNot(HaveOccurred())
+
+		return entry
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-cache-test-")
+		Expect(err).ToNot(HaveOccurred())
+
+		subject = New(filepath.Join(tmpdir, "cache"))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	Describe("Lookup", func() {
+		It("finds stored files by any key", func() {
+			stored := store("file", "content", "key1", "key2")
+
+			entry, found, err := subject.Lookup("missing", "key2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(entry.Path).To(Equal(stored.Path))
+			Expect(entry.Size).To(Equal(int64(7)))
+
+			buf, err := ioutil.ReadFile(entry.Path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buf)).To(Equal("content"))
+		})
+
+		It("stores read-only copies", func() {
+			stored := store("file", "content", "key1")
+
+			fi, err := os.Stat(stored.Path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0444)))
+
+			Expect(os.Chmod(filepath.Join(tmpdir, "file"), 0755)).To(Succeed())
+
+			fi, err = os.Stat(stored.Path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0444)))
+		})
+
+		It("ignores unknown keys", func() {
+			store("file", "content", "key1")
+
+			_, found, err := subject.Lookup("key2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("ignores files which changed size", func() {
+			stored := store("file", "content", "key1")
+			Expect(os.Chmod(stored.Path, 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(stored.Path, []byte("modified content"), 0644)).To(Succeed())
+
+			_, found, err := subject.Lookup("key1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Prune", func() {
+		It("removes least-recently used files over the size limit", func() {
+			store("file1", "content1", "key1")
+			store("file2", "content2", "key2")
+			store("file3", "content3", "key3")
+
+			// usage is tracked by the index
+			_, found, err := subject.Lookup("key1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			res, err := subject.Prune(16, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Removed).To(Equal(1))
+			Expect(res.Freed).To(Equal(int64(8)))
+
+			_, found, err = subject.Lookup("key1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+		})
+
+		It("removes files not used within the age limit", func() {
+			stored := store("file1", "content1", "key1")
+			store("file2", "content2", "key2")
+
+			matches, err := filepath.Glob(filepath.Join(tmpdir, "cache", "index", "*", "*"))
+			Expect(err).ToNot(HaveOccurred())
+
+			past := time.Now().Add(-48 * time.Hour)
+
+			for _, match := range append(matches, stored.Path) {
+				Expect(os.Chtimes(match, past, past)).To(Succeed())
+			}
+
+			_, found, err := subject.Lookup("key2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			res, err := subject.Prune(0, 24*time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Removed).To(Equal(1))
+
+			_, found, err = subject.Lookup("key1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Resource", func() {
+		It("transfers without downloading", func() {
+			stored := store("file", "content", "key1")
+			target := filepath.Join(tmpdir, "result")
+
+			steps := []transfer.Step{
+				&step.TempFileTarget{Target: target},
+				&step.VerifyChecksum{Verifier: checksum.NewHashVerifier(checksum.SHA256, stored.SHA256, sha256.New())},
+				&step.Rename{Target: target},
+			}
+
+			xfer := transfer.NewTransfer(NewResource("file", *stored), steps, transfer.Options{RetryPolicy: retry.NewPolicy(0)})
+			xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+
+			Expect(xfer.Execute(context.Background())).To(Succeed())
+
+			buf, err := ioutil.ReadFile(target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buf)).To(Equal("content"))
+		})
+	})
+})
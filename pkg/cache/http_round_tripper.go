@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxHTTPResponseSize avoids buffering large responses (e.g. file contents) which are better left to the download
+// cache.
+const maxHTTPResponseSize = 4 * 1024 * 1024
+
+// OfflineError indicates a request could not be satisfied without network access.
+type OfflineError struct {
+	URL string
+}
+
+func (e OfflineError) Error() string {
+	return fmt.Sprintf("no cached response for %s (offline)", e.URL)
+}
+
+type HTTPOptions struct {
+	// Mode is whether cached responses are used and whether new responses are stored.
+	Mode Mode
+
+	// MaxAge is how long a cached response may be used before it is revalidated with the server.
+	MaxAge time.Duration
+
+	// Offline only uses cached responses, regardless of their age.
+	Offline bool
+}
+
+// NewHTTPRoundTripper caches successful GET responses and revalidates them with conditional requests (If-None-Match,
+// If-Modified-Since) which do not count against API rate limits.
+func (c *Cache) NewHTTPRoundTripper(log logrus.FieldLogger, rt http.RoundTripper, opts HTTPOptions) http.RoundTripper {
+	return httpRoundTripper{
+		log:  log,
+		rt:   rt,
+		dir:  filepath.Join(c.dir, "http"),
+		opts: opts,
+	}
+}
+
+type httpRoundTripper struct {
+	log  logrus.FieldLogger
+	rt   http.RoundTripper
+	dir  string
+	opts HTTPOptions
+}
+
+func (rt httpRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("range") != "" {
+		if rt.opts.Offline {
+			return nil, OfflineError{URL: req.URL.String()}
+		}
+
+		return rt.rt.RoundTrip(req)
+	}
+
+	path := rt.entryPath(req)
+
+	var cached *http.Response
+	var storedAt time.Time
+
+	if rt.opts.Mode.Readable() {
+		var err error
+
+		cached, storedAt, err = readHTTPEntry(path, req)
+		if err != nil {
+			if !os.IsNotExist(errors.Cause(err)) {
+				rt.log.Warnf("http cache: %s %s (ignoring entry: %s)", req.Method, req.URL.String(), err)
+			}
+
+			cached = nil
+		}
+	}
+
+	if cached != nil {
+		if rt.opts.Offline || (rt.opts.MaxAge > 0 && time.Since(storedAt) <= rt.opts.MaxAge) {
+			rt.log.Debugf("http cache: %s %s (using cached response)", req.Method, req.URL.String())
+
+			return cached, nil
+		}
+	} else if rt.opts.Offline {
+		return nil, OfflineError{URL: req.URL.String()}
+	}
+
+	attemptReq := req
+
+	if cached != nil {
+		attemptReq = req.Clone(req.Context())
+
+		if v := cached.Header.Get("etag"); v != "" {
+			attemptReq.Header.Set("if-none-match", v)
+		}
+
+		if v := cached.Header.Get("last-modified"); v != "" {
+			attemptReq.Header.Set("if-modified-since", v)
+		}
+	}
+
+	res, err := rt.rt.RoundTrip(attemptReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+
+		rt.log.Debugf("http cache: %s %s (revalidated cached response)", req.Method, req.URL.String())
+
+		now := time.Now()
+		os.Chtimes(path, now, now)
+
+		return cached, nil
+	}
+
+	if res.StatusCode != http.StatusOK || !rt.opts.Mode.Writable() {
+		return res, nil
+	} else if res.Header.Get("etag") == "" && res.Header.Get("last-modified") == "" && rt.opts.MaxAge == 0 {
+		// never reusable
+		return res, nil
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(res.Body, maxHTTPResponseSize+1))
+	if err != nil {
+		res.Body.Close()
+
+		return nil, errors.Wrap(err, "reading response")
+	}
+
+	if len(buf) > maxHTTPResponseSize {
+		res.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(buf), res.Body),
+			Closer: res.Body,
+		}
+
+		return res, nil
+	}
+
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	res.ContentLength = int64(len(buf))
+	res.TransferEncoding = nil
+
+	err = writeHTTPEntry(path, res, buf)
+	if err != nil {
+		rt.log.Warnf("http cache: %s %s (failed to store response: %s)", req.Method, req.URL.String(), err)
+	}
+
+	return res, nil
+}
+
+// entryPath distinguishes requests by headers which commonly affect the response, including authentication.
+func (rt httpRoundTripper) entryPath(req *http.Request) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s\n", req.URL.String())
+
+	for _, header := range []string{"accept", "authorization", "private-token"} {
+		fmt.Fprintf(h, "%s: %s\n", header, req.Header.Get(header))
+	}
+
+	digestHex := hex.EncodeToString(h.Sum(nil))
+
+	return filepath.Join(rt.dir, digestHex[0:2], digestHex)
+}
+
+func readHTTPEntry(path string, req *http.Request) (*http.Response, time.Time, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "checking")
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(fh), req)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "parsing")
+	}
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "reading")
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(buf))
+
+	return res, fi.ModTime(), nil
+}
+
+func writeHTTPEntry(path string, res *http.Response, body []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	tmpPath := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+
+	fh, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "creating")
+	}
+
+	stored := *res
+	stored.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err = stored.Write(fh)
+	if err != nil {
+		fh.Close()
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "writing")
+	}
+
+	err = fh.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "closing")
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "renaming")
+	}
+
+	return nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
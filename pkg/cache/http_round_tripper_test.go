@@ -0,0 +1,106 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/dpb587/gget/pkg/cache"
+)
+
+var _ = Describe("HTTPRoundTripper", func() {
+	var tmpdir string
+	var server *httptest.Server
+	var requests []string
+	var log logrus.FieldLogger
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-cache-test-")
+		Expect(err).ToNot(HaveOccurred())
+
+		requests = nil
+		log, _ = logrustest.NewNullLogger()
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Header.Get("if-none-match"))
+
+			if r.Header.Get("if-none-match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			w.Header().Set("etag", `"v1"`)
+			w.Write([]byte("response"))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	get := func(opts HTTPOptions) (string, error) {
+		client := &http.Client{
+			Transport: New(tmpdir).NewHTTPRoundTripper(log, http.DefaultTransport, opts),
+		}
+
+		res, err := client.Get(server.URL)
+		if err != nil {
+			return "", err
+		}
+
+		defer res.Body.Close()
+
+		buf, err := ioutil.ReadAll(res.Body)
+
+		return string(buf), err
+	}
+
+	It("revalidates cached responses", func() {
+		Expect(get(HTTPOptions{Mode: ReadWriteMode})).To(Equal("response"))
+		Expect(get(HTTPOptions{Mode: ReadWriteMode})).To(Equal("response"))
+		Expect(requests).To(Equal([]string{"", `"v1"`}))
+	})
+
+	It("uses fresh responses without revalidating", func() {
+		Expect(get(HTTPOptions{Mode: ReadWriteMode})).To(Equal("response"))
+		Expect(get(HTTPOptions{Mode: ReadWriteMode, MaxAge: time.Hour})).To(Equal("response"))
+		Expect(requests).To(Equal([]string{""}))
+	})
+
+	It("does not store responses when read-only", func() {
+		Expect(get(HTTPOptions{Mode: ReadMode})).To(Equal("response"))
+		Expect(get(HTTPOptions{Mode: ReadWriteMode})).To(Equal("response"))
+		Expect(requests).To(Equal([]string{"", ""}))
+	})
+
+	It("does not use cached responses when write-only", func() {
+		Expect(get(HTTPOptions{Mode: ReadWriteMode})).To(Equal("response"))
+		Expect(get(HTTPOptions{Mode: WriteMode})).To(Equal("response"))
+		Expect(requests).To(Equal([]string{"", ""}))
+	})
+
+	Context("offline", func() {
+		It("uses cached responses", func() {
+			Expect(get(HTTPOptions{Mode: ReadWriteMode})).To(Equal("response"))
+			Expect(get(HTTPOptions{Mode: ReadMode, Offline: true})).To(Equal("response"))
+			Expect(requests).To(HaveLen(1))
+		})
+
+		It("fails without a cached response", func() {
+			_, err := get(HTTPOptions{Mode: ReadMode, Offline: true})
+			Expect(err).To(MatchError(ContainSubstring("no cached response")))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/service"
+)
+
+// ChecksumKey refers to content by a checksum it was verified against.
+func ChecksumKey(algorithm checksum.Algorithm, expected []byte) string {
+	return fmt.Sprintf("checksum:%s:%x", algorithm, expected)
+}
+
+// ResourceKey refers to content by a resource of a repository ref. The commit should be included when known since
+// refs such as branches may refer to different content over time.
+func ResourceKey(ref service.Ref, commit string, resourceType service.ResourceType, name string) string {
+	return fmt.Sprintf("resource:%s#%s:%s:%s", ref, commit, resourceType, name)
+}
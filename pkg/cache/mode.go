@@ -0,0 +1,34 @@
+package cache
+
+import "fmt"
+
+type Mode string
+
+// OffMode neither uses nor updates the cache.
+const OffMode Mode = "off"
+
+// ReadMode uses previously-cached files, but does not add new downloads.
+const ReadMode Mode = "read"
+
+// WriteMode adds new downloads, but does not use previously-cached files.
+const WriteMode Mode = "write"
+
+// ReadWriteMode uses previously-cached files and adds new downloads.
+const ReadWriteMode Mode = "readwrite"
+
+func ParseMode(in string) (Mode, error) {
+	switch Mode(in) {
+	case OffMode, ReadMode, WriteMode, ReadWriteMode:
+		return Mode(in), nil
+	}
+
+	return "", fmt.Errorf("unsupported cache mode: %s", in)
+}
+
+func (m Mode) Readable() bool {
+	return m == ReadMode || m == ReadWriteMode
+}
+
+func (m Mode) Writable() bool {
+	return m == WriteMode || m == ReadWriteMode
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type PruneResult struct {
+	Removed int
+	Freed   int64
+}
+
+type pruneBlob struct {
+	path    string
+	size    int64
+	used    time.Time
+	indexes []string
+}
+
+// Prune removes files which have not been used within maxAge and then the least-recently used files until the total
+// size is no more than maxSize. A zero value disables the respective limit. Index entries which refer to missing files
+// are always removed. Cached API responses and refs are only subject to maxAge.
+func (c *Cache) Prune(maxSize int64, maxAge time.Duration) (PruneResult, error) {
+	var res PruneResult
+
+	blobs := map[string]*pruneBlob{}
+
+	err := walkFiles(filepath.Join(c.dir, "blobs", "sha256"), func(path string, fi os.FileInfo) error {
+		if strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		blobs[fi.Name()] = &pruneBlob{
+			path: path,
+			size: fi.Size(),
+			used: fi.ModTime(),
+		}
+
+		return nil
+	})
+	if err != nil {
+		return res, errors.Wrap(err, "finding files")
+	}
+
+	err = walkFiles(filepath.Join(c.dir, "index"), func(path string, fi os.FileInfo) error {
+		if strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		record, err := readIndexRecord(path)
+		if err != nil {
+			return err
+		}
+
+		blob, found := blobs[record.SHA256]
+		if !found {
+			return removeFile(path)
+		}
+
+		blob.indexes = append(blob.indexes, path)
+
+		if fi.ModTime().After(blob.used) {
+			blob.used = fi.ModTime()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return res, errors.Wrap(err, "finding index")
+	}
+
+	var sorted []*pruneBlob
+	var total int64
+
+	for _, blob := range blobs {
+		sorted = append(sorted, blob)
+		total += blob.size
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].used.Before(sorted[j].used)
+	})
+
+	now := time.Now()
+
+	for _, blob := range sorted {
+		expired := maxAge > 0 && now.Sub(blob.used) > maxAge
+		oversized := maxSize > 0 && total > maxSize
+
+		if !expired && !oversized {
+			continue
+		}
+
+		for _, indexPath := range blob.indexes {
+			err = removeFile(indexPath)
+			if err != nil {
+				return res, err
+			}
+		}
+
+		err = removeFile(blob.path)
+		if err != nil {
+			return res, err
+		}
+
+		total -= blob.size
+		res.Removed++
+		res.Freed += blob.size
+	}
+
+	if maxAge > 0 {
+		for _, dir := range []string{"http", "refs"} {
+			err = walkFiles(filepath.Join(c.dir, dir), func(path string, fi os.FileInfo) error {
+				if now.Sub(fi.ModTime()) <= maxAge {
+					return nil
+				}
+
+				res.Removed++
+				res.Freed += fi.Size()
+
+				return removeFile(path)
+			})
+			if err != nil {
+				return res, errors.Wrapf(err, "finding %s", dir)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func walkFiles(dir string, fn func(path string, fi os.FileInfo) error) error {
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return nil
+			}
+
+			return err
+		} else if fi.IsDir() {
+			return nil
+		}
+
+		return fn(path, fi)
+	})
+}
+
+func removeFile(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing")
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/export"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/pkg/errors"
+)
+
+type refRecord struct {
+	Key  string          `json:"key"`
+	Data json.RawMessage `json:"data"`
+}
+
+// RefKey refers to the resolution of a user-provided ref, including the options which affect it.
+func RefKey(ref service.Ref, resourceType service.ResourceType, versions, stability []string) string {
+	return fmt.Sprintf(
+		"ref:%s:%s:%s?version=%s&stability=%s",
+		ref.Service,
+		ref,
+		resourceType,
+		strings.Join(versions, ","),
+		strings.Join(stability, ","),
+	)
+}
+
+// LookupRef loads a ref and its resources previously recorded by StoreRef.
+func (c *Cache) LookupRef(key string, checksumVerification checksum.VerificationProfile) (*export.Data, bool, error) {
+	refPath := c.refPath(key)
+
+	buf, err := ioutil.ReadFile(refPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, errors.Wrap(err, "reading ref")
+	}
+
+	var record refRecord
+
+	err = json.Unmarshal(buf, &record)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "parsing ref")
+	} else if record.Key != key {
+		return nil, false, nil
+	}
+
+	data, err := export.ImportJSON(record.Data, checksumVerification)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "importing ref")
+	}
+
+	now := time.Now()
+	os.Chtimes(refPath, now, now)
+
+	return data, true, nil
+}
+
+// StoreRef records a resolved ref and its resources so they may be resolved without network access later. Resources
+// previously recorded for the same ref and metadata are kept.
+func (c *Cache) StoreRef(ctx context.Context, key string, data *export.Data) error {
+	metadata, err := data.Metadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting metadata")
+	}
+
+	resources := data.Resources()
+
+	existing, found, err := c.LookupRef(key, data.ChecksumVerification())
+	if err != nil {
+		return errors.Wrap(err, "checking existing ref")
+	} else if found && existing.Origin() == data.Origin() {
+		existingMetadata, err := existing.Metadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "getting existing metadata")
+		}
+
+		if reflect.DeepEqual(existingMetadata, metadata) {
+			known := map[string]struct{}{}
+
+			for _, resource := range resources {
+				known[resource.GetName()] = struct{}{}
+			}
+
+			for _, resource := range existing.Resources() {
+				if _, found := known[resource.GetName()]; !found {
+					resources = append(resources, resource)
+				}
+			}
+		}
+	}
+
+	merged := export.NewData(data.Origin(), data.Metadata, resources, data.ChecksumVerification())
+
+	exportBuf := &bytes.Buffer{}
+
+	err = export.JSONExporter{}.Export(ctx, exportBuf, merged)
+	if err != nil {
+		return errors.Wrap(err, "exporting ref")
+	}
+
+	buf, err := json.Marshal(refRecord{
+		Key:  key,
+		Data: exportBuf.Bytes(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshaling")
+	}
+
+	return writeFile(c.refPath(key), buf)
+}
+
+func (c *Cache) refPath(key string) string {
+	keyDigest := sha256.Sum256([]byte(key))
+	keyHex := hex.EncodeToString(keyDigest[:])
+
+	return filepath.Join(c.dir, "refs", keyHex[0:2], keyHex)
+}
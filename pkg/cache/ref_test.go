@@ -0,0 +1,138 @@
+package cache_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/export"
+	"github.com/dpb587/gget/pkg/service"
+)
+
+type testResource struct {
+	name    string
+	content string
+}
+
+func (r testResource) GetName() string {
+	return r.name
+}
+
+func (r testResource) GetSize() int64 {
+	return int64(len(r.content))
+}
+
+func (r testResource) Open(_ context.Context) (io.ReadCloser, error) {
+	panic("unexpected")
+}
+
+func (r testResource) GetChecksums(_ context.Context, _ checksum.AlgorithmList) (checksum.ChecksumList, error) {
+	digest := sha256.Sum256([]byte(r.content))
+
+	return checksum.ChecksumList{checksum.NewHashChecksum(checksum.SHA256, digest[:], sha256.New)}, nil
+}
+
+var _ = Describe("Ref", func() {
+	var tmpdir string
+	var subject *Cache
+	var ctx context.Context
+	var origin service.Ref
+	var profile checksum.VerificationProfile
+
+	metadataGetter := func(commit string) func(context.Context) (service.RefMetadata, error) {
+		return func(_ context.Context) (service.RefMetadata, error) {
+			return service.RefMetadata{{Name: "commit", Value: commit}}, nil
+		}
+	}
+
+	resourceNames := func(data *export.Data) []string {
+		var res []string
+
+		for _, resource := range data.Resources() {
+			res = append(res, resource.GetName())
+		}
+
+		return res
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-cache-test-")
+		Expect(err).ToNot(HaveOccurred())
+
+		subject = New(tmpdir)
+		ctx = context.Background()
+		origin = service.Ref{Service: "github", Server: "github.com", Owner: "dpb587", Repository: "gget", Ref: "v1.0.0"}
+		profile = checksum.VerificationProfile{
+			Acceptable: checksum.AlgorithmsByStrength,
+			Selector:   checksum.StrongestChecksumSelector{},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	It("recalls stored refs", func() {
+		key := RefKey(origin, service.AssetResourceType, nil, nil)
+
+		err := subject.StoreRef(ctx, key, export.NewData(origin, metadataGetter("abc123"), []service.ResolvedResource{testResource{"file", "content"}}, profile))
+		Expect(err).ToNot(HaveOccurred())
+
+		data, found, err := subject.LookupRef(key, profile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(data.Origin()).To(Equal(origin))
+
+		metadata, err := data.Metadata(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metadata).To(Equal(service.RefMetadata{{Name: "commit", Value: "abc123"}}))
+
+		resolved, err := export.NewResolvedRef(data).ResolveResource(ctx, service.AssetResourceType, "f*")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved).To(HaveLen(1))
+		Expect(resolved[0].GetSize()).To(Equal(int64(7)))
+
+		checksums, err := resolved[0].(service.ChecksumSupportedResolvedResource).GetChecksums(ctx, checksum.AlgorithmList{checksum.SHA256})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(HaveLen(1))
+	})
+
+	It("merges resources of the same ref", func() {
+		key := RefKey(origin, service.AssetResourceType, nil, nil)
+
+		Expect(subject.StoreRef(ctx, key, export.NewData(origin, metadataGetter("abc123"), []service.ResolvedResource{testResource{"file1", "content1"}}, profile))).To(Succeed())
+		Expect(subject.StoreRef(ctx, key, export.NewData(origin, metadataGetter("abc123"), []service.ResolvedResource{testResource{"file2", "content2"}}, profile))).To(Succeed())
+
+		data, _, err := subject.LookupRef(key, profile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resourceNames(data)).To(ConsistOf("file1", "file2"))
+	})
+
+	It("replaces resources of a changed ref", func() {
+		key := RefKey(origin, service.AssetResourceType, nil, nil)
+
+		Expect(subject.StoreRef(ctx, key, export.NewData(origin, metadataGetter("abc123"), []service.ResolvedResource{testResource{"file1", "content1"}}, profile))).To(Succeed())
+		Expect(subject.StoreRef(ctx, key, export.NewData(origin, metadataGetter("def456"), []service.ResolvedResource{testResource{"file2", "content2"}}, profile))).To(Succeed())
+
+		data, _, err := subject.LookupRef(key, profile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resourceNames(data)).To(ConsistOf("file2"))
+	})
+
+	It("distinguishes lookup options", func() {
+		Expect(subject.StoreRef(ctx, RefKey(origin, service.AssetResourceType, nil, nil), export.NewData(origin, metadataGetter("abc123"), nil, profile))).To(Succeed())
+
+		_, found, err := subject.LookupRef(RefKey(origin, service.AssetResourceType, []string{"1.x"}, nil), profile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+})
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/dpb587/gget/pkg/transfer"
+)
+
+// Resource is a cached file which may be transferred in place of the original resource.
+type Resource struct {
+	name  string
+	entry Entry
+}
+
+var _ transfer.DownloadAsset = &Resource{}
+var _ transfer.LocalDownloadAsset = &Resource{}
+
+func NewResource(name string, entry Entry) *Resource {
+	return &Resource{
+		name:  name,
+		entry: entry,
+	}
+}
+
+func (r *Resource) GetName() string {
+	return r.name
+}
+
+func (r *Resource) GetSize() int64 {
+	return r.entry.Size
+}
+
+func (r *Resource) GetLocalPath() string {
+	return r.entry.Path
+}
+
+func (r *Resource) Open(_ context.Context) (io.ReadCloser, error) {
+	return os.Open(r.entry.Path)
+}
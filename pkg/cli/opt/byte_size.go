@@ -0,0 +1,20 @@
+package opt
+
+import (
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/pkg/errors"
+)
+
+// ByteSize supports human-friendly sizes (e.g. 512K, 1G).
+type ByteSize uint64
+
+func (o *ByteSize) UnmarshalFlag(data string) error {
+	parsed, err := bytefmt.ToBytes(data)
+	if err != nil {
+		return errors.Wrap(err, "parsing byte size")
+	}
+
+	*o = ByteSize(parsed)
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package opt
+
+import (
+	"github.com/dpb587/gget/pkg/cache"
+	"github.com/pkg/errors"
+)
+
+type CacheMode cache.Mode
+
+func (o *CacheMode) UnmarshalFlag(data string) error {
+	parsed, err := cache.ParseMode(data)
+	if err != nil {
+		return errors.Wrap(err, "parsing cache option")
+	}
+
+	*o = CacheMode(parsed)
+
+	return nil
+}
@@ -33,3 +33,13 @@ func (cl ConstraintList) Constraints() []*semver.Constraints {
 
 	return res
 }
+
+func (cl ConstraintList) RawValues() []string {
+	var res []string
+
+	for _, v := range cl {
+		res = append(res, v.RawValue)
+	}
+
+	return res
+}
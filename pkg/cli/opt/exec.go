@@ -0,0 +1,51 @@
+package opt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Exec is a command to run after downloading resources which match a glob.
+type Exec struct {
+	Matcher ResourceMatcher
+	Command string
+}
+
+func (o *Exec) UnmarshalFlag(data string) error {
+	// commands commonly include = (e.g. environment variables), but globs should not
+	split := strings.SplitN(data, "=", 2)
+	if len(split) != 2 || split[0] == "" || strings.TrimSpace(split[1]) == "" {
+		return fmt.Errorf("expected GLOB=COMMAND: %s", data)
+	}
+
+	matcher := ResourceMatcher(split[0])
+
+	err := matcher.Validate()
+	if err != nil {
+		return errors.Wrap(err, "parsing exec option")
+	}
+
+	o.Matcher = matcher
+	o.Command = split[1]
+
+	return nil
+}
+
+type ExecList []Exec
+
+// Commands returns the commands for a resource in the order they were specified.
+func (o ExecList) Commands(remote string) []string {
+	var res []string
+
+	for _, e := range o {
+		if !e.Matcher.Match(remote) {
+			continue
+		}
+
+		res = append(res, e.Command)
+	}
+
+	return res
+}
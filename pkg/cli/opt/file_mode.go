@@ -0,0 +1,53 @@
+package opt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileMode is the permissions for resources which match a glob.
+type FileMode struct {
+	Matcher ResourceMatcher
+	Mode    os.FileMode
+}
+
+func (o *FileMode) UnmarshalFlag(data string) error {
+	split := strings.SplitN(data, "=", 2)
+	if len(split) != 2 || split[0] == "" {
+		return fmt.Errorf("expected GLOB=MODE: %s", data)
+	}
+
+	matcher := ResourceMatcher(split[0])
+
+	err := matcher.Validate()
+	if err != nil {
+		return errors.Wrap(err, "parsing mode option")
+	}
+
+	mode, err := strconv.ParseUint(split[1], 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return fmt.Errorf("expected octal permissions (e.g. 0644): %s", split[1])
+	}
+
+	o.Matcher = matcher
+	o.Mode = os.FileMode(mode)
+
+	return nil
+}
+
+type FileModeList []FileMode
+
+// Mode returns the permissions of the last matching glob, if any.
+func (o FileModeList) Mode(remote string) (os.FileMode, bool) {
+	for i := len(o) - 1; i >= 0; i-- {
+		if o[i].Matcher.Match(remote) {
+			return o[i].Mode, true
+		}
+	}
+
+	return 0, false
+}
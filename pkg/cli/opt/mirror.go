@@ -0,0 +1,31 @@
+package opt
+
+import (
+	"github.com/dpb587/gget/pkg/mirror"
+	"github.com/pkg/errors"
+)
+
+type Mirror mirror.Rule
+
+func (o *Mirror) UnmarshalFlag(data string) error {
+	parsed, err := mirror.ParseRule(data)
+	if err != nil {
+		return errors.Wrap(err, "parsing mirror option")
+	}
+
+	*o = Mirror(parsed)
+
+	return nil
+}
+
+type MirrorList []Mirror
+
+func (o MirrorList) Rules() mirror.Rules {
+	var res mirror.Rules
+
+	for _, m := range o {
+		res = append(res, mirror.Rule(m))
+	}
+
+	return res
+}
@@ -0,0 +1,19 @@
+package opt
+
+import (
+	"github.com/dpb587/gget/pkg/ratelimit"
+	"github.com/pkg/errors"
+)
+
+type RateLimit ratelimit.Mode
+
+func (o *RateLimit) UnmarshalFlag(data string) error {
+	parsed, err := ratelimit.ParseMode(data)
+	if err != nil {
+		return errors.Wrap(err, "parsing rate limit option")
+	}
+
+	*o = RateLimit(parsed)
+
+	return nil
+}
@@ -1,21 +1,20 @@
 package opt
 
 import (
-	"path/filepath"
-
+	"github.com/dpb587/gget/pkg/globutil"
 	"github.com/pkg/errors"
 )
 
 type ResourceMatcher string
 
 func (o *ResourceMatcher) Match(remote string) bool {
-	match, _ := filepath.Match(string(*o), remote)
+	match, _ := globutil.Match(string(*o), remote)
 
 	return match
 }
 
 func (o *ResourceMatcher) Validate() error {
-	_, err := filepath.Match(string(*o), "test")
+	err := globutil.Validate(string(*o))
 	if err != nil {
 		return errors.Wrap(err, "expected valid Resource matcher")
 	}
@@ -0,0 +1,19 @@
+package opt
+
+import (
+	"github.com/dpb587/gget/pkg/transfer/transferutil"
+	"github.com/pkg/errors"
+)
+
+type SkipMode transferutil.SkipMode
+
+func (o *SkipMode) UnmarshalFlag(data string) error {
+	parsed, err := transferutil.ParseSkipMode(data)
+	if err != nil {
+		return errors.Wrap(err, "parsing skip existing option")
+	}
+
+	*o = SkipMode(parsed)
+
+	return nil
+}
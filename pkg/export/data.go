@@ -59,3 +59,7 @@ func (d *Data) Metadata(ctx context.Context) (service.RefMetadata, error) {
 func (d *Data) Resources() []service.ResolvedResource {
 	return d.resources
 }
+
+func (d *Data) ChecksumVerification() checksum.VerificationProfile {
+	return d.checksumVerification
+}
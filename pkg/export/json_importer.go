@@ -0,0 +1,112 @@
+package export
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// ImportJSON loads data previously written by JSONExporter. Imported resources only describe names, sizes, and
+// checksums; their content cannot be opened.
+func ImportJSON(buf []byte, checksumVerification checksum.VerificationProfile) (*Data, error) {
+	var raw marshalData
+
+	err := json.Unmarshal(buf, &raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling")
+	}
+
+	origin := service.Ref{
+		Service:    raw.Origin.Service,
+		Server:     raw.Origin.Server,
+		Owner:      raw.Origin.Owner,
+		Repository: raw.Origin.Repository,
+		Ref:        raw.Origin.Ref,
+	}
+
+	metadata := service.RefMetadata{}
+
+	for _, metadatum := range raw.Metadata {
+		metadata = append(metadata, service.RefMetadatum{
+			Name:  metadatum.Key,
+			Value: metadatum.Value,
+		})
+	}
+
+	var resources []service.ResolvedResource
+
+	for _, rawResource := range raw.Resources {
+		resource := &importedResource{
+			name: rawResource.Name,
+			url:  rawResource.URL,
+		}
+
+		if rawResource.Size != nil {
+			resource.size = *rawResource.Size
+		}
+
+		for _, rawChecksum := range rawResource.Checksums {
+			expected, err := hex.DecodeString(rawChecksum.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding checksum of %s", rawResource.Name)
+			}
+
+			cs, err := checksum.GuessChecksum(expected)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing checksum of %s", rawResource.Name)
+			} else if cs.Algorithm() != checksum.Algorithm(rawChecksum.Algo) {
+				return nil, fmt.Errorf("checksum of %s does not match algorithm: %s", rawResource.Name, rawChecksum.Algo)
+			}
+
+			resource.checksums = append(resource.checksums, cs)
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return NewData(
+		origin,
+		func(_ context.Context) (service.RefMetadata, error) {
+			return metadata, nil
+		},
+		resources,
+		checksumVerification,
+	), nil
+}
+
+type importedResource struct {
+	name      string
+	size      int64
+	url       string
+	checksums checksum.ChecksumList
+}
+
+var _ service.ResolvedResource = &importedResource{}
+var _ service.ChecksumSupportedResolvedResource = &importedResource{}
+var _ service.DownloadURLResolvedResource = &importedResource{}
+
+func (r *importedResource) GetName() string {
+	return r.name
+}
+
+func (r *importedResource) GetSize() int64 {
+	return r.size
+}
+
+func (r *importedResource) GetDownloadURL() string {
+	return r.url
+}
+
+func (r *importedResource) GetChecksums(_ context.Context, algos checksum.AlgorithmList) (checksum.ChecksumList, error) {
+	return r.checksums.FilterAlgorithms(algos), nil
+}
+
+func (r *importedResource) Open(_ context.Context) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("content of imported resource is unavailable: %s", r.name)
+}
@@ -75,11 +75,18 @@ func newMarshalData(ctx context.Context, data *Data) (marshalData, error) {
 			}
 		}
 
+		var url string
+
+		if dur, ok := resource.(service.DownloadURLResolvedResource); ok {
+			url = dur.GetDownloadURL()
+		}
+
 		res.Resources = append(
 			res.Resources,
 			marshalDataResource{
 				Name:      resource.GetName(),
 				Size:      size,
+				URL:       url,
 				Checksums: jsonChecksums,
 			},
 		)
@@ -115,6 +122,7 @@ type marshalDataMetadatum struct {
 type marshalDataResource struct {
 	Name      string                        `json:"name"`
 	Size      *int64                        `json:"size,omitempty"`
+	URL       string                        `json:"url,omitempty"`
 	Checksums []marshalDataResourceChecksum `json:"checksums,omitempty"`
 }
 
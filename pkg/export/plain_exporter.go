@@ -41,6 +41,10 @@ func (e PlainExporter) Export(ctx context.Context, w io.Writer, data *Data) erro
 				fmt.Fprintf(w, "resource-size\t%s\t%d\n", resource.Name, resource.Size)
 			}
 
+			if resource.URL != "" {
+				fmt.Fprintf(w, "resource-url\t%s\t%s\n", resource.Name, resource.URL)
+			}
+
 			for _, checksum := range resource.Checksums {
 				fmt.Fprintf(w, "resource-checksum\t%s\t%s\t%s\n", resource.Name, checksum.Algo, checksum.Data)
 			}
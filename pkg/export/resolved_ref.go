@@ -0,0 +1,43 @@
+package export
+
+import (
+	"context"
+
+	"github.com/dpb587/gget/pkg/globutil"
+	"github.com/dpb587/gget/pkg/service"
+)
+
+// ResolvedRef replays previously exported data as if it had been resolved by a service.
+type ResolvedRef struct {
+	data *Data
+}
+
+var _ service.ResolvedRef = &ResolvedRef{}
+
+func NewResolvedRef(data *Data) *ResolvedRef {
+	return &ResolvedRef{
+		data: data,
+	}
+}
+
+func (r *ResolvedRef) CanonicalRef() service.Ref {
+	return r.data.Origin()
+}
+
+func (r *ResolvedRef) GetMetadata(ctx context.Context) (service.RefMetadata, error) {
+	return r.data.Metadata(ctx)
+}
+
+func (r *ResolvedRef) ResolveResource(_ context.Context, _ service.ResourceType, resource service.ResourceName) ([]service.ResolvedResource, error) {
+	var res []service.ResolvedResource
+
+	for _, candidate := range r.data.Resources() {
+		if match, _ := globutil.Match(string(resource), candidate.GetName()); !match {
+			continue
+		}
+
+		res = append(res, candidate)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package fsutil
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Copy writes the content of src to a new file at dst.
+func Copy(src, dst string) error {
+	srcfh, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "opening source")
+	}
+
+	defer srcfh.Close()
+
+	fi, err := srcfh.Stat()
+	if err != nil {
+		return errors.Wrap(err, "checking source")
+	}
+
+	dstfh, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if err != nil {
+		return errors.Wrap(err, "creating destination")
+	}
+
+	_, err = io.Copy(dstfh, srcfh)
+	if err != nil {
+		dstfh.Close()
+		os.Remove(dst)
+
+		return errors.Wrap(err, "copying")
+	}
+
+	err = dstfh.Close()
+	if err != nil {
+		os.Remove(dst)
+
+		return errors.Wrap(err, "closing destination")
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package gitlfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const mediaType = "application/vnd.git-lfs+json"
+
+// BatchClient requests download locations of objects from the LFS server of a repository.
+type BatchClient struct {
+	client   *http.Client
+	endpoint string
+}
+
+// NewBatchClient uses the endpoint of a repository, typically the repository URL with .git/info/lfs appended.
+func NewBatchClient(client *http.Client, endpoint string) *BatchClient {
+	return &BatchClient{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+// Download describes where the content of an object may be downloaded from.
+type Download struct {
+	Href   string
+	Header http.Header
+}
+
+type batchObject struct {
+	OID     string                 `json:"oid"`
+	Size    int64                  `json:"size"`
+	Actions map[string]batchAction `json:"actions,omitempty"`
+	Error   *batchError            `json:"error,omitempty"`
+}
+
+type batchAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+type batchError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type batchRequest struct {
+	Operation string        `json:"operation"`
+	Transfers []string      `json:"transfers"`
+	Objects   []batchObject `json:"objects"`
+}
+
+type batchResponse struct {
+	Objects []batchObject `json:"objects"`
+}
+
+// GetDownload requests the download location of an object. Locations may expire, so they should not be reused.
+func (c *BatchClient) GetDownload(ctx context.Context, pointer Pointer) (Download, error) {
+	reqBody, err := json.Marshal(batchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects: []batchObject{
+			{
+				OID:  pointer.OID,
+				Size: pointer.Size,
+			},
+		},
+	})
+	if err != nil {
+		return Download{}, errors.Wrap(err, "marshaling request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/objects/batch", c.endpoint), bytes.NewReader(reqBody))
+	if err != nil {
+		return Download{}, errors.Wrap(err, "building request")
+	}
+
+	req.Header.Set("accept", mediaType)
+	req.Header.Set("content-type", mediaType)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return Download{}, errors.Wrap(err, "requesting batch")
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Download{}, fmt.Errorf("requesting batch: expected status 200: got %d", res.StatusCode)
+	}
+
+	var batch batchResponse
+
+	err = json.NewDecoder(res.Body).Decode(&batch)
+	if err != nil {
+		return Download{}, errors.Wrap(err, "decoding batch")
+	}
+
+	for _, object := range batch.Objects {
+		if object.OID != pointer.OID {
+			continue
+		} else if object.Error != nil {
+			return Download{}, fmt.Errorf("requesting object %s: %s (code %d)", pointer.OID, object.Error.Message, object.Error.Code)
+		}
+
+		action, ok := object.Actions["download"]
+		if !ok {
+			return Download{}, fmt.Errorf("requesting object %s: missing download action", pointer.OID)
+		}
+
+		header := http.Header{}
+
+		for k, v := range action.Header {
+			header.Set(k, v)
+		}
+
+		return Download{
+			Href:   action.Href,
+			Header: header,
+		}, nil
+	}
+
+	return Download{}, fmt.Errorf("requesting object %s: missing from batch", pointer.OID)
+}
@@ -0,0 +1,13 @@
+package gitlfs_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestGitlfs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "github.com/dpb587/gget/pkg/gitlfs")
+}
@@ -0,0 +1,97 @@
+package gitlfs
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+const pointerVersion = "https://git-lfs.github.com/spec/v1"
+
+// maxPointerSize is the size which git-lfs itself considers when looking for pointers.
+const maxPointerSize = 1024
+
+// minPointerSize is the smallest possible pointer (version, oid and a single-digit size).
+var minPointerSize = int64(len("version " + pointerVersion + "\noid sha256:" + strings.Repeat("0", 64) + "\nsize 0\n"))
+
+// Pointer references the content of a file which is stored outside of the repository.
+type Pointer struct {
+	OID  string
+	Size int64
+}
+
+// MaybePointer returns whether a blob of the size could be a pointer. Blobs of an unknown (zero) size may be a pointer.
+func MaybePointer(size int64) bool {
+	return size == 0 || (size >= minPointerSize && size < maxPointerSize)
+}
+
+// ReadPointer parses the content as a pointer. Content which is not a pointer is not an error and returns nil.
+func ReadPointer(r io.Reader) (*Pointer, error) {
+	buf, err := ioutil.ReadAll(io.LimitReader(r, maxPointerSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading content")
+	} else if len(buf) == maxPointerSize {
+		return nil, nil
+	}
+
+	return ParsePointer(buf), nil
+}
+
+// ParsePointer parses the content as a pointer, returning nil if it is not a valid pointer.
+func ParsePointer(buf []byte) *Pointer {
+	if !bytes.HasSuffix(buf, []byte("\n")) {
+		return nil
+	}
+
+	var res Pointer
+	var version, sized bool
+
+	for lineIdx, line := range strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n") {
+		kv := strings.SplitN(line, " ", 2)
+		if len(kv) != 2 {
+			return nil
+		}
+
+		switch kv[0] {
+		case "version":
+			if lineIdx != 0 || kv[1] != pointerVersion {
+				return nil
+			}
+
+			version = true
+		case "oid":
+			oid := strings.TrimPrefix(kv[1], "sha256:")
+			if oid == kv[1] || len(oid) != 64 {
+				return nil
+			} else if _, err := hex.DecodeString(oid); err != nil {
+				return nil
+			}
+
+			res.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil || size < 0 {
+				return nil
+			}
+
+			res.Size = size
+			sized = true
+		default:
+			if !strings.HasPrefix(kv[0], "ext-") {
+				return nil
+			}
+		}
+	}
+
+	if !version || !sized || res.OID == "" {
+		return nil
+	}
+
+	return &res
+}
@@ -0,0 +1,40 @@
+package gitlfs_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/gitlfs"
+)
+
+var _ = Describe("Pointer", func() {
+	oid := "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+	It("parses pointers", func() {
+		pointer := ParsePointer([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n"))
+		Expect(pointer).To(Equal(&Pointer{OID: oid, Size: 12345}))
+	})
+
+	It("ignores other content", func() {
+		Expect(ParsePointer([]byte("hello world\n"))).To(BeNil())
+		Expect(ParsePointer([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n"))).To(BeNil())
+		Expect(ParsePointer([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345"))).To(BeNil())
+		Expect(ParsePointer([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 12345\n"))).To(BeNil())
+		Expect(ParsePointer([]byte("oid sha256:" + oid + "\nversion https://git-lfs.github.com/spec/v1\nsize 12345\n"))).To(BeNil())
+	})
+
+	It("ignores large content", func() {
+		pointer, err := ReadPointer(strings.NewReader("version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n" + strings.Repeat("\n", 1024)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pointer).To(BeNil())
+	})
+
+	It("considers sizes", func() {
+		Expect(MaybePointer(0)).To(BeTrue())
+		Expect(MaybePointer(130)).To(BeTrue())
+		Expect(MaybePointer(12)).To(BeFalse())
+		Expect(MaybePointer(4096)).To(BeFalse())
+	})
+})
@@ -0,0 +1,77 @@
+package gitlfs
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/tidwall/limiter"
+)
+
+// Resolve replaces blobs whose content is a pointer with the object they reference. Only blobs which may be a pointer
+// based on their size are downloaded and inspected.
+func Resolve(ctx context.Context, client *BatchClient, blobs []service.ResolvedResource) ([]service.ResolvedResource, error) {
+	res := make([]service.ResolvedResource, len(blobs))
+	errs := make([]error, len(blobs))
+
+	var wg sync.WaitGroup
+
+	l := limiter.New(4)
+
+	for blobIdx, blob := range blobs {
+		res[blobIdx] = blob
+
+		if !MaybePointer(blob.GetSize()) {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(blobIdx int, blob service.ResolvedResource) {
+			defer wg.Done()
+
+			l.Begin()
+			defer l.End()
+
+			pointer, err := readPointer(ctx, blob)
+			if err != nil {
+				errs[blobIdx] = errors.Wrapf(err, "checking %s for lfs pointer", blob.GetName())
+			} else if pointer != nil {
+				res[blobIdx] = NewResource(blob, client, *pointer)
+			}
+		}(blobIdx, blob)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func readPointer(ctx context.Context, blob service.ResolvedResource) (*Pointer, error) {
+	var fh io.ReadCloser
+	var err error
+
+	if ro, ok := blob.(transfer.RangeDownloadAsset); ok {
+		// avoid starting to download large blobs of an unknown size
+		fh, err = ro.OpenRange(ctx, 0, maxPointerSize, "")
+	} else {
+		fh, err = blob.Open(ctx)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	return ReadPointer(fh)
+}
@@ -0,0 +1,193 @@
+package gitlfs_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	. "github.com/dpb587/gget/pkg/gitlfs"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/dpb587/gget/pkg/service/gitlab/blob"
+	"github.com/xanzy/go-gitlab"
+)
+
+type testBlob struct {
+	name    string
+	content string
+}
+
+func (b testBlob) GetName() string {
+	return b.name
+}
+
+func (b testBlob) GetSize() int64 {
+	return int64(len(b.content))
+}
+
+func (b testBlob) Open(ctx context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(b.content)), nil
+}
+
+var _ = Describe("Resolve", func() {
+	var server *httptest.Server
+	var object, oid string
+
+	BeforeEach(func() {
+		object = "large content"
+
+		sum := sha256.Sum256([]byte(object))
+		oid = hex.EncodeToString(sum[:])
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/repo.git/info/lfs/objects/batch":
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("content-type")).To(Equal("application/vnd.git-lfs+json"))
+
+				var req map[string]interface{}
+				Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+				Expect(req["operation"]).To(Equal("download"))
+
+				fmt.Fprintf(w, `{"objects":[{"oid":%q,"size":%d,"actions":{"download":{"href":"%s/objects/%s","header":{"X-Token":"secret"}}}}]}`, oid, len(object), "http://"+r.Host, oid)
+			case "/objects/" + oid:
+				Expect(r.Header.Get("x-token")).To(Equal("secret"))
+
+				w.Write([]byte(object))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("replaces pointers with their objects", func() {
+		client := NewBatchClient(http.DefaultClient, server.URL+"/repo.git/info/lfs")
+
+		res, err := Resolve(context.Background(), client, []service.ResolvedResource{
+			testBlob{name: "README.md", content: "hello\n"},
+			testBlob{name: "data.bin", content: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 13\n"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(HaveLen(2))
+
+		Expect(res[0]).To(Equal(testBlob{name: "README.md", content: "hello\n"}))
+
+		Expect(res[1].GetName()).To(Equal("data.bin"))
+		Expect(res[1].GetSize()).To(Equal(int64(13)))
+
+		csr, ok := res[1].(service.ChecksumSupportedResolvedResource)
+		Expect(ok).To(BeTrue())
+
+		cs, err := csr.GetChecksums(context.Background(), checksum.AlgorithmList{checksum.SHA256})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cs).To(HaveLen(1))
+
+		verifier, err := cs[0].NewVerifier(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		fh, err := res[1].Open(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		defer fh.Close()
+
+		buf, err := ioutil.ReadAll(io.TeeReader(fh, verifier))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(buf)).To(Equal(object))
+		Expect(verifier.Verify()).To(Succeed())
+	})
+
+	It("only checks gitlab blobs which may be a pointer", func() {
+		var blobRequests int32
+
+		pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 13\n"
+
+		gitlabServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&blobRequests, 1)
+
+			switch r.URL.Path {
+			case "/api/v4/projects/owner/repo/repository/blobs/large/raw":
+				w.Write([]byte(strings.Repeat("0", 4096)))
+			case "/api/v4/projects/owner/repo/repository/blobs/data/raw":
+				w.Write([]byte(pointer))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer gitlabServer.Close()
+
+		gitlabClient, err := gitlab.NewClient("", gitlab.WithBaseURL(gitlabServer.URL+"/api/v4"))
+		Expect(err).ToNot(HaveOccurred())
+
+		newBlob := func(id, path string, size int64) service.ResolvedResource {
+			return blob.NewResource(
+				gitlabClient,
+				http.DefaultClient,
+				service.Ref{Server: "gitlab.com", Owner: "owner", Repository: "repo", Ref: "master"},
+				"abc123",
+				&gitlab.TreeNode{ID: id, Path: path, Type: "blob", Mode: "100644"},
+				size,
+				nil,
+			)
+		}
+
+		client := NewBatchClient(http.DefaultClient, server.URL+"/repo.git/info/lfs")
+
+		res, err := Resolve(context.Background(), client, []service.ResolvedResource{
+			newBlob("large", "large.txt", 4096),
+			newBlob("data", "data.bin", int64(len(pointer))),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(HaveLen(2))
+		Expect(atomic.LoadInt32(&blobRequests)).To(Equal(int32(1)))
+
+		Expect(res[0].GetName()).To(Equal("large.txt"))
+		Expect(res[0].GetSize()).To(Equal(int64(4096)))
+
+		Expect(res[1].GetName()).To(Equal("data.bin"))
+		Expect(res[1].GetSize()).To(Equal(int64(13)))
+
+		csr, ok := res[1].(service.ChecksumSupportedResolvedResource)
+		Expect(ok).To(BeTrue())
+
+		cs, err := csr.GetChecksums(context.Background(), checksum.AlgorithmList{checksum.SHA256})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cs).To(HaveLen(1))
+
+		fh, err := res[1].Open(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		buf, err := ioutil.ReadAll(fh)
+		fh.Close()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(buf)).To(Equal(object))
+		Expect(atomic.LoadInt32(&blobRequests)).To(Equal(int32(1)))
+	})
+
+	It("fails for missing objects", func() {
+		client := NewBatchClient(http.DefaultClient, server.URL+"/other.git/info/lfs")
+
+		res, err := Resolve(context.Background(), client, []service.ResolvedResource{
+			testBlob{name: "data.bin", content: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 13\n"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = res[0].Open(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("expected status 200: got 404"))
+	})
+})
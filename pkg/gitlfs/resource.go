@@ -0,0 +1,104 @@
+package gitlfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/httputil"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+)
+
+// Resource is the object of a pointer blob. The name and download URL remain those of the blob.
+type Resource struct {
+	service.ResolvedResource
+
+	client  *BatchClient
+	pointer Pointer
+}
+
+var _ service.ResolvedResource = &Resource{}
+var _ service.ChecksumSupportedResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
+var _ service.TreeModeResolvedResource = &Resource{}
+var _ transfer.RangeDownloadAsset = &Resource{}
+
+func NewResource(blob service.ResolvedResource, client *BatchClient, pointer Pointer) *Resource {
+	return &Resource{
+		ResolvedResource: blob,
+		client:           client,
+		pointer:          pointer,
+	}
+}
+
+func (r *Resource) GetSize() int64 {
+	return r.pointer.Size
+}
+
+func (r *Resource) GetChecksums(ctx context.Context, algos checksum.AlgorithmList) (checksum.ChecksumList, error) {
+	if !algos.Contains(checksum.SHA256) {
+		return nil, nil
+	}
+
+	expected, err := hex.DecodeString(r.pointer.OID)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding oid")
+	}
+
+	return checksum.ChecksumList{
+		checksum.NewHashChecksum(checksum.SHA256, expected, sha256.New),
+	}, nil
+}
+
+func (r *Resource) GetDownloadURL() string {
+	if dur, ok := r.ResolvedResource.(service.DownloadURLResolvedResource); ok {
+		return dur.GetDownloadURL()
+	}
+
+	return ""
+}
+
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	if mtr, ok := r.ResolvedResource.(service.ModTimeResolvedResource); ok {
+		return mtr.GetModTime(ctx)
+	}
+
+	return time.Time{}, nil
+}
+
+func (r *Resource) GetTreeMode() string {
+	if tmr, ok := r.ResolvedResource.(service.TreeModeResolvedResource); ok {
+		return tmr.GetTreeMode()
+	}
+
+	return ""
+}
+
+func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
+	res, err := r.OpenRange(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	download, err := r.client.GetDownload(ctx, r.pointer)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolving lfs object")
+	}
+
+	res, err := httputil.GetRangeWithHeader(ctx, r.client.client, download.Href, download.Header, offset, length, validator)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting lfs object")
+	}
+
+	return res, nil
+}
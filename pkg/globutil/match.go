@@ -0,0 +1,59 @@
+package globutil
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether a slash-separated name matches a shell pattern, like path.Match. Additionally, a "**" path
+// segment matches any number of directories (e.g. **/*.yml), and a trailing "**" matches everything below a
+// directory (e.g. deploy/**).
+func Match(pattern, name string) (bool, error) {
+	if !strings.Contains(pattern, "**") {
+		return path.Match(pattern, name)
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// Validate returns path.ErrBadPattern if the pattern is malformed.
+func Validate(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, "test"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for patternIdx, segment := range pattern {
+		if segment == "**" {
+			rest := pattern[patternIdx+1:]
+			if len(rest) == 0 {
+				return len(name) > 0, nil
+			}
+
+			for skip := 0; skip <= len(name); skip++ {
+				match, err := matchSegments(rest, name[skip:])
+				if err != nil || match {
+					return match, err
+				}
+			}
+
+			return false, nil
+		} else if len(name) == 0 {
+			return false, nil
+		}
+
+		match, err := path.Match(segment, name[0])
+		if err != nil || !match {
+			return false, err
+		}
+
+		name = name[1:]
+	}
+
+	return len(name) == 0, nil
+}
@@ -0,0 +1,55 @@
+package globutil_test
+
+import (
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/globutil"
+)
+
+var _ = Describe("Match", func() {
+	match := func(pattern, name string) bool {
+		res, err := Match(pattern, name)
+		Expect(err).ToNot(HaveOccurred())
+
+		return res
+	}
+
+	It("matches within a directory", func() {
+		Expect(match("*.yml", "a.yml")).To(BeTrue())
+		Expect(match("*.yml", "deploy/a.yml")).To(BeFalse())
+	})
+
+	It("matches everything below a directory", func() {
+		Expect(match("deploy/**", "deploy/a.yml")).To(BeTrue())
+		Expect(match("deploy/**", "deploy/env/prod/a.yml")).To(BeTrue())
+		Expect(match("deploy/**", "deploy")).To(BeFalse())
+		Expect(match("deploy/**", "deployment/a.yml")).To(BeFalse())
+	})
+
+	It("matches any number of leading directories", func() {
+		Expect(match("**/*.yml", "a.yml")).To(BeTrue())
+		Expect(match("**/*.yml", "deploy/env/a.yml")).To(BeTrue())
+		Expect(match("**/*.yml", "deploy/env/a.json")).To(BeFalse())
+	})
+
+	It("matches any number of inner directories", func() {
+		Expect(match("deploy/**/values.yml", "deploy/env/prod/values.yml")).To(BeTrue())
+		Expect(match("deploy/**/values.yml", "deploy/values.yml")).To(BeTrue())
+		Expect(match("deploy/**/values.yml", "other/env/values.yml")).To(BeFalse())
+	})
+
+	It("rejects bad patterns", func() {
+		_, err := Match("deploy/**/[", "deploy/a")
+		Expect(err).To(Equal(path.ErrBadPattern))
+	})
+})
+
+var _ = Describe("Validate", func() {
+	It("checks every segment", func() {
+		Expect(Validate("deploy/**/*.yml")).To(Succeed())
+		Expect(Validate("deploy/[/**")).To(Equal(path.ErrBadPattern))
+	})
+})
@@ -0,0 +1,17 @@
+package httputil
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Get requests the URL and returns the body of a successful response.
+func Get(ctx context.Context, client *http.Client, url string) (io.ReadCloser, error) {
+	rr, err := GetRange(ctx, client, url, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return rr.ReadCloser, nil
+}
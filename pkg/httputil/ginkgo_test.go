@@ -0,0 +1,13 @@
+package httputil_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHttputil(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "github.com/dpb587/gget/pkg/httputil")
+}
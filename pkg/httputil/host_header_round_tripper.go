@@ -0,0 +1,35 @@
+package httputil
+
+import (
+	"net/http"
+)
+
+// HostHeaderRoundTripper sets headers (e.g. credentials) on requests to a specific host. Requests to other hosts (e.g.
+// after a redirect to a storage service) are unchanged so credentials are not sent elsewhere.
+type HostHeaderRoundTripper struct {
+	Base   http.RoundTripper
+	Host   string
+	Header http.Header
+}
+
+var _ http.RoundTripper = &HostHeaderRoundTripper{}
+
+func (rt *HostHeaderRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := rt.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.URL.Host != rt.Host {
+		return base.RoundTrip(req)
+	}
+
+	// round trippers should not modify the original request
+	req = req.Clone(req.Context())
+
+	for k, v := range rt.Header {
+		req.Header[k] = v
+	}
+
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,55 @@
+package httputil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/httputil"
+)
+
+var _ = Describe("HostHeaderRoundTripper", func() {
+	var api, storage *httptest.Server
+	var apiAuth, storageAuth, storageAccept string
+
+	BeforeEach(func() {
+		storage = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			storageAuth = r.Header.Get("private-token")
+			storageAccept = r.Header.Get("accept")
+			w.Write([]byte("content"))
+		}))
+
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiAuth = r.Header.Get("private-token")
+			http.Redirect(w, r, storage.URL, http.StatusFound)
+		}))
+	})
+
+	AfterEach(func() {
+		api.Close()
+		storage.Close()
+	})
+
+	It("only sends headers to the host", func() {
+		apiURL, _ := url.Parse(api.URL)
+
+		client := &http.Client{
+			Transport: &HostHeaderRoundTripper{
+				Host:   apiURL.Host,
+				Header: http.Header{"Private-Token": []string{"secret"}},
+			},
+		}
+
+		rc, err := GetRangeWithHeader(context.Background(), client, api.URL, http.Header{"Accept": []string{"application/octet-stream"}}, 0, 0, "")
+		Expect(err).ToNot(HaveOccurred())
+		rc.Close()
+
+		Expect(apiAuth).To(Equal("secret"))
+		Expect(storageAuth).To(BeEmpty())
+		Expect(storageAccept).To(Equal("application/octet-stream"))
+	})
+})
@@ -0,0 +1,137 @@
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RangeReader is the body of a download which may have started after the beginning of the content.
+type RangeReader struct {
+	io.ReadCloser
+
+	// Offset is the position of the first byte of the body within the full content.
+	Offset int64
+
+	// Size is the full content size, or 0 if unknown.
+	Size int64
+
+	// Validator may be used to request further ranges of the same content; empty if ranges are not supported.
+	Validator string
+}
+
+// GetRange requests the URL, starting from offset if the validator still matches the remote content. A non-zero
+// length limits the request to a specific range. Servers may respond with the full content which is indicated by a
+// zero Offset.
+func GetRange(ctx context.Context, client *http.Client, url string, offset, length int64, validator string) (*RangeReader, error) {
+	return GetRangeWithHeader(ctx, client, url, nil, offset, length, validator)
+}
+
+// GetRangeWithHeader is similar to GetRange, but includes additional request headers (e.g. an API media type).
+func GetRangeWithHeader(ctx context.Context, client *http.Client, url string, header http.Header, offset, length int64, validator string) (*RangeReader, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "building request")
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if length > 0 {
+		req.Header.Set("range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else if offset > 0 && validator != "" {
+		req.Header.Set("range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	if validator != "" && req.Header.Get("range") != "" {
+		req.Header.Set("if-range", validator)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		res.Body.Close()
+
+		// likely a stale offset; start over
+		return GetRangeWithHeader(ctx, client, url, header, 0, 0, "")
+	}
+
+	rr := &RangeReader{
+		ReadCloser: res.Body,
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		if res.ContentLength > 0 {
+			rr.Size = res.ContentLength
+		}
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(res.Header.Get("content-range"))
+		if err != nil {
+			res.Body.Close()
+
+			return nil, errors.Wrap(err, "parsing content range")
+		}
+
+		rr.Offset = start
+		rr.Size = size
+	default:
+		res.Body.Close()
+
+		return nil, fmt.Errorf("expected status 200: got %d", res.StatusCode)
+	}
+
+	if res.StatusCode == http.StatusPartialContent || strings.ToLower(res.Header.Get("accept-ranges")) == "bytes" {
+		rr.Validator = parseValidator(res.Header)
+	}
+
+	return rr, nil
+}
+
+func parseValidator(header http.Header) string {
+	// weak entity tags cannot be used for range requests
+	if etag := header.Get("etag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return header.Get("last-modified")
+}
+
+func parseContentRange(value string) (int64, int64, error) {
+	// bytes 100-199/200
+	if !strings.HasPrefix(value, "bytes ") {
+		return 0, 0, fmt.Errorf("unsupported unit: %s", value)
+	}
+
+	rangeSize := strings.SplitN(strings.TrimPrefix(value, "bytes "), "/", 2)
+	if len(rangeSize) != 2 {
+		return 0, 0, fmt.Errorf("invalid format: %s", value)
+	}
+
+	startEnd := strings.SplitN(rangeSize[0], "-", 2)
+
+	start, err := strconv.ParseInt(startEnd[0], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parsing start")
+	}
+
+	var size int64
+
+	if rangeSize[1] != "*" {
+		size, err = strconv.ParseInt(rangeSize[1], 10, 64)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "parsing size")
+		}
+	}
+
+	return start, size, nil
+}
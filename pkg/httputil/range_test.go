@@ -0,0 +1,66 @@
+package httputil_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/httputil"
+)
+
+var _ = Describe("GetRange", func() {
+	var server *httptest.Server
+	var etag string
+
+	BeforeEach(func() {
+		etag = `"v1"`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("etag", etag)
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader([]byte("0123456789")))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("downloads everything", func() {
+		rr, err := GetRange(context.Background(), http.DefaultClient, server.URL, 0, 0, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		buf, _ := ioutil.ReadAll(rr)
+		Expect(string(buf)).To(Equal("0123456789"))
+		Expect(rr.Offset).To(Equal(int64(0)))
+		Expect(rr.Size).To(Equal(int64(10)))
+		Expect(rr.Validator).To(Equal(`"v1"`))
+	})
+
+	It("continues from an offset", func() {
+		rr, err := GetRange(context.Background(), http.DefaultClient, server.URL, 4, 0, `"v1"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		buf, _ := ioutil.ReadAll(rr)
+		Expect(string(buf)).To(Equal("456789"))
+		Expect(rr.Offset).To(Equal(int64(4)))
+		Expect(rr.Size).To(Equal(int64(10)))
+	})
+
+	It("starts over when content changed", func() {
+		etag = `"v2"`
+
+		rr, err := GetRange(context.Background(), http.DefaultClient, server.URL, 4, 0, `"v1"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		buf, _ := ioutil.ReadAll(rr)
+		Expect(string(buf)).To(Equal("0123456789"))
+		Expect(rr.Offset).To(Equal(int64(0)))
+		Expect(rr.Validator).To(Equal(`"v2"`))
+	})
+})
@@ -0,0 +1,132 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Record describes an installed binary and the request which installed it, so it may be upgraded later.
+type Record struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+
+	// Origin is the requested repository (and optional ref) which is resolved again when upgrading.
+	Origin         string   `json:"origin"`
+	RefVersions    []string `json:"ref_versions,omitempty"`
+	RefStability   []string `json:"ref_stability,omitempty"`
+	Resource       string   `json:"resource,omitempty"`
+	ExtractPaths   []string `json:"extract_paths,omitempty"`
+	VerifyChecksum []string `json:"verify_checksum,omitempty"`
+
+	// Ref is the resolved, canonical ref of the installed version.
+	Ref         string    `json:"ref"`
+	Asset       string    `json:"asset"`
+	SHA256      string    `json:"sha256"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+type databaseFile struct {
+	Installed []Record `json:"installed"`
+}
+
+// Database tracks installed binaries by name in a JSON file.
+type Database struct {
+	path    string
+	records map[string]Record
+}
+
+// OpenDatabase reads the database; a missing file is an empty database.
+func OpenDatabase(path string) (*Database, error) {
+	db := &Database{
+		path:    path,
+		records: map[string]Record{},
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return db, nil
+		}
+
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	var file databaseFile
+
+	err = json.Unmarshal(buf, &file)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	for _, record := range file.Installed {
+		db.records[record.Name] = record
+	}
+
+	return db, nil
+}
+
+func (db *Database) Get(name string) (Record, bool) {
+	record, found := db.records[name]
+
+	return record, found
+}
+
+func (db *Database) Put(record Record) {
+	db.records[record.Name] = record
+}
+
+func (db *Database) Delete(name string) {
+	delete(db.records, name)
+}
+
+// List returns the records ordered by name.
+func (db *Database) List() []Record {
+	var res []Record
+
+	for _, record := range db.records {
+		res = append(res, record)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// Save writes the database, replacing the previous file atomically.
+func (db *Database) Save() error {
+	buf, err := json.MarshalIndent(databaseFile{Installed: db.List()}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshaling")
+	}
+
+	err = os.MkdirAll(filepath.Dir(db.path), 0755)
+	if err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	dir, base := filepath.Split(db.path)
+	tmpPath := filepath.Join(dir, fmt.Sprintf(".gget-%s.tmp", base))
+
+	err = ioutil.WriteFile(tmpPath, append(buf, '\n'), 0644)
+	if err != nil {
+		return errors.Wrap(err, "writing")
+	}
+
+	err = os.Rename(tmpPath, db.path)
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "renaming")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package install_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/install"
+)
+
+var _ = Describe("Database", func() {
+	var tmpdir string
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-install-test-")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	It("is empty when missing", func() {
+		db, err := OpenDatabase(filepath.Join(tmpdir, "missing.json"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.List()).To(BeEmpty())
+	})
+
+	It("round-trips records by name", func() {
+		path := filepath.Join(tmpdir, "nested", "installed.json")
+		installedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		db, err := OpenDatabase(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		db.Put(Record{Name: "zeta", Origin: "github.com/example/zeta", Ref: "github.com/example/zeta@v1.0.0", InstalledAt: installedAt})
+		db.Put(Record{Name: "alpha", Origin: "github.com/example/alpha", RefVersions: []string{"1.x"}, Ref: "github.com/example/alpha@v1.2.0", InstalledAt: installedAt})
+		Expect(db.Save()).To(Succeed())
+
+		db, err = OpenDatabase(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		records := db.List()
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].Name).To(Equal("alpha"))
+		Expect(records[0].RefVersions).To(Equal([]string{"1.x"}))
+		Expect(records[0].InstalledAt).To(Equal(installedAt))
+		Expect(records[1].Name).To(Equal("zeta"))
+
+		db.Delete("zeta")
+		Expect(db.Save()).To(Succeed())
+
+		db, err = OpenDatabase(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found := db.Get("zeta")
+		Expect(found).To(BeFalse())
+
+		record, found := db.Get("alpha")
+		Expect(found).To(BeTrue())
+		Expect(record.Ref).To(Equal("github.com/example/alpha@v1.2.0"))
+
+		matches, _ := filepath.Glob(filepath.Join(tmpdir, "nested", ".gget-*"))
+		Expect(matches).To(BeEmpty())
+	})
+})
@@ -0,0 +1,62 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/export"
+	"github.com/pkg/errors"
+)
+
+// ChecksumVerification keeps every recorded checksum in lock files.
+var ChecksumVerification = checksum.VerificationProfile{
+	Acceptable: checksum.AlgorithmsByStrength,
+	Selector:   checksum.AllChecksumSelector{},
+}
+
+// Read loads a lock file previously written by Write.
+func Read(path string) (*export.Data, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	data, err := export.ImportJSON(buf, ChecksumVerification)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	return data, nil
+}
+
+// Write replaces the lock file with the data. Resources should be lock resources (see NewResource) in order to include
+// their verified checksums.
+func Write(ctx context.Context, path string, data *export.Data) error {
+	buf := &bytes.Buffer{}
+
+	err := export.JSONExporter{}.Export(ctx, buf, export.NewData(data.Origin(), data.Metadata, data.Resources(), ChecksumVerification))
+	if err != nil {
+		return errors.Wrap(err, "exporting")
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(path), fmt.Sprintf(".gget-%s.tmp", filepath.Base(path)))
+
+	err = ioutil.WriteFile(tmpPath, buf.Bytes(), 0644)
+	if err != nil {
+		return errors.Wrap(err, "writing")
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Wrap(err, "renaming")
+	}
+
+	return nil
+}
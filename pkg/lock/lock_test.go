@@ -0,0 +1,132 @@
+package lock_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/export"
+	. "github.com/dpb587/gget/pkg/lock"
+	"github.com/dpb587/gget/pkg/service"
+)
+
+type testResource struct {
+	name string
+	size int64
+}
+
+func (r testResource) GetName() string {
+	return r.name
+}
+
+func (r testResource) GetSize() int64 {
+	return r.size
+}
+
+func (r testResource) GetDownloadURL() string {
+	return "https://example.com/" + r.name
+}
+
+func (r testResource) Open(_ context.Context) (io.ReadCloser, error) {
+	panic("unexpected")
+}
+
+type testRef struct {
+	ref    service.Ref
+	commit string
+}
+
+func (r testRef) CanonicalRef() service.Ref {
+	return r.ref
+}
+
+func (r testRef) GetMetadata(_ context.Context) (service.RefMetadata, error) {
+	return service.RefMetadata{{Name: "commit", Value: r.commit}}, nil
+}
+
+func (r testRef) ResolveResource(_ context.Context, _ service.ResourceType, _ service.ResourceName) ([]service.ResolvedResource, error) {
+	panic("unexpected")
+}
+
+var _ = Describe("Lock", func() {
+	var tmpdir string
+	var ctx context.Context
+	var ref testRef
+	var locked *export.Data
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-lock-test-")
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx = context.Background()
+		ref = testRef{
+			ref:    service.Ref{Service: "github", Server: "github.com", Owner: "dpb587", Repository: "gget", Ref: "v1.0.0"},
+			commit: "abc123",
+		}
+
+		resource := NewResource(testResource{name: "file", size: 7})
+
+		digest := sha256.Sum256([]byte("content"))
+		resource.ChecksumRecorder().AddChecksum("file", checksum.NewHashChecksum(checksum.SHA256, digest[:], sha256.New))
+
+		lockPath := filepath.Join(tmpdir, "gget.lock")
+
+		Expect(Write(ctx, lockPath, export.NewData(ref.ref, ref.GetMetadata, []service.ResolvedResource{resource}, ChecksumVerification))).To(Succeed())
+
+		locked, err = Read(lockPath)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	It("records resources", func() {
+		Expect(locked.Origin()).To(Equal(ref.ref))
+		Expect(locked.Resources()).To(HaveLen(1))
+		Expect(locked.Resources()[0].(service.DownloadURLResolvedResource).GetDownloadURL()).To(Equal("https://example.com/file"))
+
+		checksums, err := Checksums(ctx, locked, "file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(HaveLen(1))
+
+		verifier, err := checksums[0].NewVerifier(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		verifier.Write([]byte("content"))
+		Expect(verifier.Verify()).To(Succeed())
+	})
+
+	Describe("Verify", func() {
+		It("accepts unchanged resources", func() {
+			Expect(Verify(ctx, locked, ref, []service.ResolvedResource{testResource{name: "file", size: 7}})).To(Succeed())
+		})
+
+		It("fails when the ref moved", func() {
+			ref.commit = "def456"
+
+			Expect(Verify(ctx, locked, ref, []service.ResolvedResource{testResource{name: "file", size: 7}})).To(MatchError(ContainSubstring("ref moved")))
+		})
+
+		It("fails when a resource disappeared", func() {
+			Expect(Verify(ctx, locked, ref, nil)).To(MatchError(ContainSubstring("locked resource not found: file")))
+		})
+
+		It("fails for unlocked resources", func() {
+			Expect(Verify(ctx, locked, ref, []service.ResolvedResource{testResource{name: "file", size: 7}, testResource{name: "other"}})).To(MatchError(ContainSubstring("resource not found in lock file: other")))
+		})
+
+		It("fails when a resource size changed", func() {
+			Expect(Verify(ctx, locked, ref, []service.ResolvedResource{testResource{name: "file", size: 8}})).To(MatchError(ContainSubstring("resource size changed")))
+		})
+	})
+})
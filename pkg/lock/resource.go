@@ -0,0 +1,43 @@
+package lock
+
+import (
+	"context"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/service"
+)
+
+// Resource describes a resolved resource with the checksums which were verified while downloading it.
+type Resource struct {
+	service.ResolvedResource
+
+	checksums checksum.WriteableManager
+}
+
+var _ service.ResolvedResource = &Resource{}
+var _ service.ChecksumSupportedResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+
+func NewResource(resource service.ResolvedResource) *Resource {
+	return &Resource{
+		ResolvedResource: resource,
+		checksums:        checksum.NewInMemoryManager(),
+	}
+}
+
+// ChecksumRecorder should receive the checksums of the content once it has been verified.
+func (r *Resource) ChecksumRecorder() checksum.WriteableManager {
+	return r.checksums
+}
+
+func (r *Resource) GetChecksums(ctx context.Context, algos checksum.AlgorithmList) (checksum.ChecksumList, error) {
+	return r.checksums.GetChecksums(ctx, r.GetName(), algos)
+}
+
+func (r *Resource) GetDownloadURL() string {
+	if dur, ok := r.ResolvedResource.(service.DownloadURLResolvedResource); ok {
+		return dur.GetDownloadURL()
+	}
+
+	return ""
+}
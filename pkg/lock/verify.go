@@ -0,0 +1,106 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/export"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// Verify returns an error if the resolved ref or resources drifted from what was locked.
+func Verify(ctx context.Context, locked *export.Data, ref service.ResolvedRef, resources []service.ResolvedResource) error {
+	if expected, actual := locked.Origin().String(), ref.CanonicalRef().String(); expected != actual {
+		return fmt.Errorf("ref changed: expected %s, but resolved %s", expected, actual)
+	}
+
+	lockedMetadata, err := locked.Metadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting locked metadata")
+	}
+
+	metadata, err := ref.GetMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting metadata")
+	}
+
+	if expected, actual := findCommit(lockedMetadata), findCommit(metadata); expected != actual {
+		return fmt.Errorf("ref moved: expected commit %s, but resolved %s", expected, actual)
+	}
+
+	resolvedByName := map[string]service.ResolvedResource{}
+
+	for _, resource := range resources {
+		resolvedByName[resource.GetName()] = resource
+	}
+
+	var missing []string
+
+	for _, lockedResource := range locked.Resources() {
+		name := lockedResource.GetName()
+
+		resource, found := resolvedByName[name]
+		if !found {
+			missing = append(missing, name)
+
+			continue
+		}
+
+		delete(resolvedByName, name)
+
+		if expected, actual := lockedResource.GetSize(), resource.GetSize(); expected > 0 && actual > 0 && expected != actual {
+			return fmt.Errorf("resource size changed: %s (expected %d, but resolved %d)", name, expected, actual)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+
+		return fmt.Errorf("locked resource not found: %s", missing[0])
+	}
+
+	if len(resolvedByName) > 0 {
+		var unexpected []string
+
+		for name := range resolvedByName {
+			unexpected = append(unexpected, name)
+		}
+
+		sort.Strings(unexpected)
+
+		return fmt.Errorf("resource not found in lock file: %s", unexpected[0])
+	}
+
+	return nil
+}
+
+// Checksums returns the locked checksums of a resource.
+func Checksums(ctx context.Context, locked *export.Data, name string) (checksum.ChecksumList, error) {
+	for _, resource := range locked.Resources() {
+		if resource.GetName() != name {
+			continue
+		}
+
+		csr, ok := resource.(service.ChecksumSupportedResolvedResource)
+		if !ok {
+			return nil, nil
+		}
+
+		return csr.GetChecksums(ctx, checksum.AlgorithmsByStrength)
+	}
+
+	return nil, nil
+}
+
+func findCommit(metadata service.RefMetadata) string {
+	for _, metadatum := range metadata {
+		if metadatum.Name == "commit" {
+			return metadatum.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,70 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest describes the repositories and resources to download in a single batch.
+type Manifest struct {
+	// Mirrors are PREFIX=MIRROR rules which are used after any from the command line.
+	Mirrors      []string     `yaml:"mirrors,omitempty"`
+	Repositories []Repository `yaml:"repositories"`
+}
+
+// Repository mirrors the command line options of a single invocation.
+type Repository struct {
+	Ref            string   `yaml:"ref"`
+	RefStability   []string `yaml:"ref_stability,omitempty"`
+	RefVersions    []string `yaml:"ref_version,omitempty"`
+	Service        string   `yaml:"service,omitempty"`
+	Type           string   `yaml:"type,omitempty"`
+	ArchiveLocal   bool     `yaml:"archive_local,omitempty"`
+	ArchivePath    string   `yaml:"archive_path,omitempty"`
+	AutoPlatform   bool     `yaml:"auto_platform,omitempty"`
+	OS             string   `yaml:"os,omitempty"`
+	Arch           string   `yaml:"arch,omitempty"`
+	Resources      []string `yaml:"resources,omitempty"`
+	Exclude        []string `yaml:"exclude,omitempty"`
+	IgnoreMissing  []string `yaml:"ignore_missing,omitempty"`
+	Executable     []string `yaml:"executable,omitempty"`
+	Decompress     []string `yaml:"decompress,omitempty"`
+	Exec           []string `yaml:"exec,omitempty"`
+	Mode           []string `yaml:"mode,omitempty"`
+	PreserveMtime  bool     `yaml:"preserve_mtime,omitempty"`
+	VerifyChecksum []string `yaml:"verify_checksum,omitempty"`
+
+	Extract         bool     `yaml:"extract,omitempty"`
+	ExtractPath     []string `yaml:"extract_path,omitempty"`
+	StripComponents int      `yaml:"strip_components,omitempty"`
+	StripPrefix     string   `yaml:"strip_prefix,omitempty"`
+}
+
+func Read(path string) (*Manifest, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	return Parse(buf)
+}
+
+func Parse(buf []byte) (*Manifest, error) {
+	var res Manifest
+
+	err := yaml.UnmarshalStrict(buf, &res)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	for idx, repository := range res.Repositories {
+		if repository.Ref == "" {
+			return nil, fmt.Errorf("repository %d: missing ref", idx)
+		}
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,55 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/manifest"
+)
+
+var _ = Describe("Parse", func() {
+	It("parses repositories", func() {
+		res, err := Parse([]byte(`
+repositories:
+- ref: github.com/cloudfoundry/bosh-cli
+  ref_version: [5.x]
+  resources: [bosh=bosh-cli-*-linux-amd64]
+  executable: ["*"]
+  verify_checksum: [sha256]
+- ref: github.com/dpb587/gget
+  type: blob
+  resources: [README.md]
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Repositories).To(Equal([]Repository{
+			{
+				Ref:            "github.com/cloudfoundry/bosh-cli",
+				RefVersions:    []string{"5.x"},
+				Resources:      []string{"bosh=bosh-cli-*-linux-amd64"},
+				Executable:     []string{"*"},
+				VerifyChecksum: []string{"sha256"},
+			},
+			{
+				Ref:       "github.com/dpb587/gget",
+				Type:      "blob",
+				Resources: []string{"README.md"},
+			},
+		}))
+	})
+
+	It("parses mirrors", func() {
+		res, err := Parse([]byte("mirrors: [github.com=mirror.example.com/github]\nrepositories:\n- ref: github.com/dpb587/gget\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Mirrors).To(Equal([]string{"github.com=mirror.example.com/github"}))
+	})
+
+	It("requires a ref", func() {
+		_, err := Parse([]byte("repositories:\n- resources: [README.md]\n"))
+		Expect(err).To(MatchError("repository 0: missing ref"))
+	})
+
+	It("rejects unknown fields", func() {
+		_, err := Parse([]byte("repositories:\n- ref: github.com/dpb587/gget\n  resource: [README.md]\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
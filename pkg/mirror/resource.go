@@ -0,0 +1,308 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/httputil"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Resource downloads a resource from mirrors in order, falling back to the origin resource. Checksums are still those
+// of the origin. Once a source served content, later requests (e.g. resuming or segments) use the same source.
+//
+// When the origin has checksums, the content of a mirror is downloaded to a temporary file and verified before it is
+// served, so mirrors which respond with the wrong content (e.g. an error page of a proxy) are skipped as well.
+type Resource struct {
+	service.ResolvedResource
+
+	log    logrus.FieldLogger
+	client *http.Client
+	urls   []string
+
+	selected  int
+	servedURL string
+	spoolPath string
+	m         sync.Mutex
+}
+
+var _ service.ResolvedResource = &Resource{}
+var _ service.ChecksumSupportedResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
+var _ transfer.RangeDownloadAsset = &Resource{}
+
+func NewResource(log logrus.FieldLogger, client *http.Client, origin service.ResolvedResource, urls []string) *Resource {
+	return &Resource{
+		ResolvedResource: origin,
+		log:              log,
+		client:           client,
+		urls:             urls,
+		selected:         -1,
+	}
+}
+
+func (r *Resource) GetChecksums(ctx context.Context, algos checksum.AlgorithmList) (checksum.ChecksumList, error) {
+	if csr, ok := r.ResolvedResource.(service.ChecksumSupportedResolvedResource); ok {
+		return csr.GetChecksums(ctx, algos)
+	}
+
+	return nil, nil
+}
+
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	if mtr, ok := r.ResolvedResource.(service.ModTimeResolvedResource); ok {
+		return mtr.GetModTime(ctx)
+	}
+
+	return time.Time{}, nil
+}
+
+// GetDownloadURL is the URL which served the content, or the origin URL if nothing was downloaded.
+func (r *Resource) GetDownloadURL() string {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.servedURL != "" {
+		return r.servedURL
+	}
+
+	return r.originURL()
+}
+
+func (r *Resource) originURL() string {
+	if dur, ok := r.ResolvedResource.(service.DownloadURLResolvedResource); ok {
+		return dur.GetDownloadURL()
+	}
+
+	return ""
+}
+
+func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
+	res, err := r.OpenRange(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	r.m.Lock()
+	selected, spoolPath := r.selected, r.spoolPath
+	r.m.Unlock()
+
+	if spoolPath != "" {
+		return r.openSpool(spoolPath, offset, length)
+	} else if selected >= 0 {
+		return r.openSource(ctx, selected, offset, length, validator)
+	}
+
+	verified, err := r.getVerifiedChecksum(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting checksum")
+	}
+
+	for idx, url := range r.urls {
+		var res *httputil.RangeReader
+		var err error
+
+		if verified != nil {
+			res, err = r.openVerifiedSource(ctx, idx, verified, offset, length)
+		} else {
+			res, err = r.openSource(ctx, idx, offset, length, validator)
+		}
+
+		if err == nil {
+			r.selectSource(idx, url)
+
+			return res, nil
+		} else if ctx.Err() != nil {
+			return nil, err
+		}
+
+		r.log.Warnf("mirror of %s failed (trying next): %s", r.GetName(), err)
+	}
+
+	res, err := r.openSource(ctx, len(r.urls), offset, length, validator)
+	if err != nil {
+		return nil, err
+	}
+
+	r.selectSource(len(r.urls), r.originURL())
+
+	return res, nil
+}
+
+func (r *Resource) selectSource(idx int, url string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.selected >= 0 {
+		return
+	}
+
+	r.selected = idx
+	r.servedURL = url
+}
+
+// getVerifiedChecksum returns the strongest checksum of the origin, or nil if it has none.
+func (r *Resource) getVerifiedChecksum(ctx context.Context) (checksum.Checksum, error) {
+	checksums, err := r.GetChecksums(ctx, checksum.AlgorithmsByStrength)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums = checksum.StrongestChecksumSelector{}.SelectChecksums(checksums)
+	if len(checksums) == 0 {
+		return nil, nil
+	}
+
+	return checksums[0], nil
+}
+
+// openVerifiedSource downloads the full content of a mirror to a temporary file and opens the range from it once the
+// content matches the checksum.
+func (r *Resource) openVerifiedSource(ctx context.Context, idx int, verified checksum.Checksum, offset, length int64) (*httputil.RangeReader, error) {
+	verifier, err := verified.NewVerifier(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting verifier")
+	}
+
+	fh, err := r.openSource(ctx, idx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	defer fh.Close()
+
+	tmpfile, err := ioutil.TempFile("", "gget-mirror-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temp file")
+	}
+
+	_, err = io.Copy(io.MultiWriter(tmpfile, verifier), fh)
+	if err != nil {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+
+		return nil, errors.Wrapf(err, "downloading mirror %s", r.urls[idx])
+	}
+
+	err = tmpfile.Close()
+	if err != nil {
+		os.Remove(tmpfile.Name())
+
+		return nil, errors.Wrap(err, "closing temp file")
+	}
+
+	err = verifier.Verify()
+	if err != nil {
+		os.Remove(tmpfile.Name())
+
+		return nil, errors.Wrapf(err, "verifying mirror %s", r.urls[idx])
+	}
+
+	r.m.Lock()
+	r.spoolPath = tmpfile.Name()
+	r.m.Unlock()
+
+	return r.openSpool(tmpfile.Name(), offset, length)
+}
+
+// openSpool opens a range of verified content. Ranges are not advertised (i.e. no validator), so segmented downloads
+// read the content in one request instead. The file is removed once the full content was read.
+func (r *Resource) openSpool(spoolPath string, offset, length int64) (*httputil.RangeReader, error) {
+	fh, err := os.Open(spoolPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening verified mirror content")
+	}
+
+	fi, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+
+		return nil, errors.Wrap(err, "checking verified mirror content")
+	}
+
+	size := fi.Size()
+	if offset > size {
+		fh.Close()
+
+		return nil, fmt.Errorf("unexpected download offset: %d is after the end of content (%d)", offset, size)
+	}
+
+	n := size - offset
+	if length > 0 && length < n {
+		n = length
+	}
+
+	closer := fh.Close
+
+	if length == 0 {
+		closer = func() error {
+			r.m.Lock()
+			if r.spoolPath == spoolPath {
+				r.spoolPath = ""
+			}
+			r.m.Unlock()
+
+			fh.Close()
+
+			return os.Remove(spoolPath)
+		}
+	}
+
+	return &httputil.RangeReader{
+		ReadCloser: spoolReader{
+			Reader: io.NewSectionReader(fh, offset, n),
+			close:  closer,
+		},
+		Offset: offset,
+		Size:   size,
+	}, nil
+}
+
+type spoolReader struct {
+	io.Reader
+
+	close func() error
+}
+
+func (r spoolReader) Close() error {
+	return r.close()
+}
+
+// openSource opens a mirror URL by index, or the origin resource for the index after all mirrors.
+func (r *Resource) openSource(ctx context.Context, idx int, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	if idx < len(r.urls) {
+		res, err := httputil.GetRange(ctx, r.client, r.urls[idx], offset, length, validator)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting mirror %s", r.urls[idx])
+		}
+
+		return res, nil
+	}
+
+	if ro, ok := r.ResolvedResource.(transfer.RangeDownloadAsset); ok {
+		return ro.OpenRange(ctx, offset, length, validator)
+	}
+
+	// the full content is indicated by the zero offset
+	res, err := r.ResolvedResource.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httputil.RangeReader{ReadCloser: res}, nil
+}
@@ -0,0 +1,182 @@
+package mirror_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/httputil"
+	. "github.com/dpb587/gget/pkg/mirror"
+)
+
+type testOrigin struct {
+	url string
+
+	// sha256 is the expected checksum of the content, if any.
+	sha256 []byte
+}
+
+func (o testOrigin) GetName() string {
+	return "file.tgz"
+}
+
+func (o testOrigin) GetSize() int64 {
+	return 0
+}
+
+func (o testOrigin) GetDownloadURL() string {
+	return o.url
+}
+
+func (o testOrigin) GetChecksums(_ context.Context, _ checksum.AlgorithmList) (checksum.ChecksumList, error) {
+	if o.sha256 == nil {
+		return nil, nil
+	}
+
+	return checksum.ChecksumList{checksum.NewHashChecksum(checksum.SHA256, o.sha256, sha256.New)}, nil
+}
+
+func (o testOrigin) Open(ctx context.Context) (io.ReadCloser, error) {
+	return httputil.Get(ctx, http.DefaultClient, o.url)
+}
+
+var _ = Describe("Resource", func() {
+	var servers []*httptest.Server
+	var log *logrus.Logger
+
+	newServer := func(status int, content string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+
+				return
+			}
+
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader([]byte(content)))
+		}))
+
+		servers = append(servers, server)
+
+		return server.URL
+	}
+
+	BeforeEach(func() {
+		servers = nil
+
+		log = logrus.New()
+		log.Out = ioutil.Discard
+	})
+
+	AfterEach(func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	})
+
+	read := func(resource *Resource) string {
+		rc, err := resource.Open(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		defer rc.Close()
+
+		buf, err := ioutil.ReadAll(rc)
+		Expect(err).ToNot(HaveOccurred())
+
+		return string(buf)
+	}
+
+	It("uses the first available mirror", func() {
+		failing := newServer(http.StatusNotFound, "")
+		working := newServer(http.StatusOK, "mirrored")
+		origin := testOrigin{url: newServer(http.StatusOK, "origin")}
+
+		resource := NewResource(log, http.DefaultClient, origin, []string{failing, working})
+
+		Expect(resource.GetDownloadURL()).To(Equal(origin.url))
+		Expect(read(resource)).To(Equal("mirrored"))
+		Expect(resource.GetDownloadURL()).To(Equal(working))
+	})
+
+	It("falls back to the origin", func() {
+		failing := newServer(http.StatusInternalServerError, "")
+		origin := testOrigin{url: newServer(http.StatusOK, "origin")}
+
+		resource := NewResource(log, http.DefaultClient, origin, []string{failing})
+
+		Expect(read(resource)).To(Equal("origin"))
+		Expect(resource.GetDownloadURL()).To(Equal(origin.url))
+	})
+
+	Context("with checksums", func() {
+		var sum []byte
+
+		BeforeEach(func() {
+			digest := sha256.Sum256([]byte("content"))
+			sum = digest[:]
+		})
+
+		It("skips mirrors with the wrong content", func() {
+			proxyError := newServer(http.StatusOK, "proxy error")
+			working := newServer(http.StatusOK, "content")
+			origin := testOrigin{url: newServer(http.StatusOK, "content"), sha256: sum}
+
+			resource := NewResource(log, http.DefaultClient, origin, []string{proxyError, working})
+
+			Expect(read(resource)).To(Equal("content"))
+			Expect(resource.GetDownloadURL()).To(Equal(working))
+		})
+
+		It("falls back to the origin", func() {
+			proxyError := newServer(http.StatusOK, "proxy error")
+			origin := testOrigin{url: newServer(http.StatusOK, "content"), sha256: sum}
+
+			resource := NewResource(log, http.DefaultClient, origin, []string{proxyError})
+
+			Expect(read(resource)).To(Equal("content"))
+			Expect(resource.GetDownloadURL()).To(Equal(origin.url))
+		})
+
+		It("serves ranges of the verified content", func() {
+			working := newServer(http.StatusOK, "content")
+			origin := testOrigin{url: newServer(http.StatusOK, "content"), sha256: sum}
+
+			resource := NewResource(log, http.DefaultClient, origin, []string{working})
+
+			res, err := resource.OpenRange(context.Background(), 0, 3, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Validator).To(BeEmpty())
+
+			buf, err := ioutil.ReadAll(res)
+			res.Close()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buf)).To(Equal("con"))
+
+			Expect(read(resource)).To(Equal("content"))
+			Expect(resource.GetDownloadURL()).To(Equal(working))
+		})
+	})
+
+	It("continues with the same source", func() {
+		working := newServer(http.StatusOK, "mirrored")
+		origin := testOrigin{url: newServer(http.StatusOK, "origin")}
+
+		resource := NewResource(log, http.DefaultClient, origin, []string{working})
+		Expect(read(resource)).To(Equal("mirrored"))
+
+		res, err := resource.OpenRange(context.Background(), 3, 0, "")
+		Expect(err).ToNot(HaveOccurred())
+		res.Close()
+
+		Expect(resource.GetDownloadURL()).To(Equal(working))
+	})
+})
@@ -0,0 +1,64 @@
+package mirror
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule rewrites URLs starting with Prefix to start with Mirror instead. Either may be a host (assuming https) or a URL
+// prefix.
+type Rule struct {
+	Prefix string
+	Mirror string
+}
+
+// ParseRule parses a PREFIX=MIRROR rule.
+func ParseRule(in string) (Rule, error) {
+	split := strings.SplitN(in, "=", 2)
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return Rule{}, fmt.Errorf("expected PREFIX=MIRROR: %s", in)
+	}
+
+	return Rule{
+		Prefix: normalize(split[0]),
+		Mirror: normalize(split[1]),
+	}, nil
+}
+
+func normalize(in string) string {
+	if !strings.Contains(in, "://") {
+		in = fmt.Sprintf("https://%s", in)
+	}
+
+	return strings.TrimSuffix(in, "/")
+}
+
+// Rewrite returns the URL at the mirror, or false if the URL does not start with the prefix (up to a path boundary).
+func (r Rule) Rewrite(url string) (string, bool) {
+	if !strings.HasPrefix(url, r.Prefix) {
+		return "", false
+	}
+
+	rest := url[len(r.Prefix):]
+	if rest != "" && rest[0] != '/' && rest[0] != '?' {
+		// e.g. github.com.example.com
+		return "", false
+	}
+
+	return fmt.Sprintf("%s%s", r.Mirror, rest), true
+}
+
+type Rules []Rule
+
+// URLs returns the mirrored URLs in the order of the rules which match.
+func (rs Rules) URLs(url string) []string {
+	var res []string
+
+	for _, rule := range rs {
+		if rewritten, ok := rule.Rewrite(url); ok {
+			res = append(res, rewritten)
+		}
+	}
+
+	return res
+}
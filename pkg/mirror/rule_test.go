@@ -0,0 +1,47 @@
+package mirror_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/mirror"
+)
+
+var _ = Describe("Rule", func() {
+	It("parses hosts as https", func() {
+		rule, err := ParseRule("github.com=mirror.example.com/artifactory/github/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rule).To(Equal(Rule{Prefix: "https://github.com", Mirror: "https://mirror.example.com/artifactory/github"}))
+	})
+
+	It("requires a mirror", func() {
+		_, err := ParseRule("github.com")
+		Expect(err).To(MatchError("expected PREFIX=MIRROR: github.com"))
+	})
+
+	It("rewrites matching prefixes", func() {
+		rule, _ := ParseRule("https://github.com/=http://mirror.example.com/github")
+
+		url, ok := rule.Rewrite("https://github.com/owner/repo/releases/download/v1/file.tgz")
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("http://mirror.example.com/github/owner/repo/releases/download/v1/file.tgz"))
+	})
+
+	It("ignores prefixes without a path boundary", func() {
+		rule, _ := ParseRule("github.com=mirror.example.com")
+
+		_, ok := rule.Rewrite("https://github.com.example.com/file")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("returns mirrors in order", func() {
+		first, _ := ParseRule("github.com=first.example.com")
+		other, _ := ParseRule("gitlab.com=other.example.com")
+		second, _ := ParseRule("github.com/owner=second.example.com")
+
+		Expect(Rules{first, other, second}.URLs("https://github.com/owner/repo")).To(Equal([]string{
+			"https://first.example.com/owner/repo",
+			"https://second.example.com/repo",
+		}))
+	})
+})
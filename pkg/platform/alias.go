@@ -0,0 +1,135 @@
+package platform
+
+// alias maps a naming convention used in file names to a normalized value.
+type alias struct {
+	Name  string
+	Value string
+}
+
+// osAliases use GOOS values. Longer names are matched first, so combined names (e.g. linux64) take precedence over their
+// parts.
+var osAliases = []alias{
+	{"linux", "linux"},
+	{"linux32", "linux"},
+	{"linux64", "linux"},
+	{"unknown-linux", "linux"},
+	{"darwin", "darwin"},
+	{"apple-darwin", "darwin"},
+	{"macos", "darwin"},
+	{"mac", "darwin"},
+	{"osx", "darwin"},
+	{"windows", "windows"},
+	{"pc-windows", "windows"},
+	{"win", "windows"},
+	{"win32", "windows"},
+	{"win64", "windows"},
+	{"freebsd", "freebsd"},
+	{"netbsd", "netbsd"},
+	{"openbsd", "openbsd"},
+	{"solaris", "solaris"},
+	{"illumos", "illumos"},
+	{"android", "android"},
+}
+
+// archAliases use GOARCH values.
+var archAliases = []alias{
+	{"amd64", "amd64"},
+	{"x86_64", "amd64"},
+	{"x86-64", "amd64"},
+	{"x64", "amd64"},
+	{"64bit", "amd64"},
+	{"64-bit", "amd64"},
+	{"linux64", "amd64"},
+	{"win64", "amd64"},
+	{"386", "386"},
+	{"i386", "386"},
+	{"i686", "386"},
+	{"x86", "386"},
+	{"32bit", "386"},
+	{"32-bit", "386"},
+	{"linux32", "386"},
+	{"win32", "386"},
+	{"arm64", "arm64"},
+	{"aarch64", "arm64"},
+	{"armv8", "arm64"},
+	{"arm", "arm"},
+	{"armv6", "arm"},
+	{"armv6l", "arm"},
+	{"armv7", "arm"},
+	{"armv7l", "arm"},
+	{"armhf", "arm"},
+	{"ppc64le", "ppc64le"},
+	{"ppc64", "ppc64"},
+	{"s390x", "s390x"},
+	{"riscv64", "riscv64"},
+	{"mips", "mips"},
+	{"mipsle", "mipsle"},
+	{"mips64", "mips64"},
+	{"mips64le", "mips64le"},
+}
+
+// universalArchAliases indicate a file which supports multiple architectures.
+var universalArchAliases = []string{
+	"universal",
+	"all",
+}
+
+// libcAliases are preferred in order since statically-linked binaries are the most portable.
+var libcAliases = []alias{
+	{"static", "static"},
+	{"musl", "musl"},
+	{"musleabihf", "musl"},
+	{"gnu", "gnu"},
+	{"gnueabihf", "gnu"},
+	{"glibc", "gnu"},
+}
+
+// ignoredExtensions are never an executable or archive for a platform.
+var ignoredExtensions = []string{
+	".asc",
+	".b2",
+	".blake2b",
+	".cert",
+	".crt",
+	".json",
+	".md",
+	".md5",
+	".pem",
+	".sbom",
+	".sha1",
+	".sha256",
+	".sha256sum",
+	".sha512",
+	".sha512sum",
+	".sig",
+	".sigstore",
+	".spdx",
+	".txt",
+}
+
+var archiveExtensions = []string{
+	".tar.gz",
+	".tgz",
+	".tar.xz",
+	".txz",
+	".tar.bz2",
+	".tbz",
+	".tar.zst",
+	".zip",
+	".gz",
+	".xz",
+	".bz2",
+	".zst",
+}
+
+// packageExtensions require a package manager and are the least preferred.
+var packageExtensions = []string{
+	".apk",
+	".appimage",
+	".deb",
+	".dmg",
+	".msi",
+	".pkg",
+	".rpm",
+	".snap",
+}
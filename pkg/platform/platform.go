@@ -0,0 +1,256 @@
+package platform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Platform is a target operating system and architecture, using GOOS and GOARCH values.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+func (p Platform) String() string {
+	return fmt.Sprintf("%s/%s", p.OS, p.Arch)
+}
+
+// Ranking describes how well a file name matches a platform.
+type Ranking struct {
+	Name string
+
+	// Compatible is false when the name refers to another platform or is not a platform-specific file (e.g. checksums).
+	Compatible bool
+
+	Score   int
+	Reasons []string
+}
+
+func (r Ranking) String() string {
+	status := fmt.Sprintf("score %d", r.Score)
+	if !r.Compatible {
+		status = "incompatible"
+	}
+
+	return fmt.Sprintf("%s (%s: %s)", r.Name, status, strings.Join(r.Reasons, "; "))
+}
+
+// Rank scores a file name by the operating system, architecture, libc, and file type it mentions.
+func (p Platform) Rank(name string) Ranking {
+	res := Ranking{
+		Name:       name,
+		Compatible: true,
+	}
+
+	lower := strings.ToLower(name)
+
+	var impliedOS string
+
+	if ext, found := matchExtension(lower, ignoredExtensions); found {
+		res.Compatible = false
+		res.Reasons = append(res.Reasons, fmt.Sprintf("ignored file type %s", ext))
+
+		return res
+	} else if ext, found := matchExtension(lower, packageExtensions); found {
+		res.Score -= 2
+		res.Reasons = append(res.Reasons, fmt.Sprintf("package %s", ext))
+	} else if ext, found := matchExtension(lower, archiveExtensions); found {
+		res.Score++
+
+		if (p.OS == "windows" && ext == ".zip") || (p.OS != "windows" && (ext == ".tar.gz" || ext == ".tgz")) {
+			res.Score++
+		}
+
+		res.Reasons = append(res.Reasons, fmt.Sprintf("archive %s", ext))
+	} else if strings.HasSuffix(lower, ".exe") {
+		impliedOS = "windows"
+		res.Score += 2
+		res.Reasons = append(res.Reasons, "binary .exe")
+	} else if p.OS != "windows" {
+		res.Score += 2
+		res.Reasons = append(res.Reasons, "binary")
+	}
+
+	{ // os
+		matched := matchAliases(lower, osAliases)
+		if impliedOS != "" {
+			matched = append(matched, alias{Name: ".exe", Value: impliedOS})
+		}
+
+		p.rankAlias(&res, "os", p.OS, matched, 10)
+	}
+
+	{ // arch
+		matched := matchAliases(lower, archAliases)
+		if len(matched) == 0 {
+			for _, universal := range universalArchAliases {
+				if findAlias(lower, universal) >= 0 {
+					res.Score += 5
+					res.Reasons = append(res.Reasons, fmt.Sprintf("arch universal (%s)", universal))
+
+					break
+				}
+			}
+		}
+
+		p.rankAlias(&res, "arch", p.Arch, matched, 10)
+	}
+
+	if p.OS == "linux" {
+		matched := matchAliases(lower, libcAliases)
+		if len(matched) > 0 {
+			switch matched[0].Value {
+			case "static":
+				res.Score += 3
+			case "musl":
+				res.Score += 2
+			case "gnu":
+				res.Score++
+			}
+
+			res.Reasons = append(res.Reasons, fmt.Sprintf("libc %s (%s)", matched[0].Value, matched[0].Name))
+		}
+	}
+
+	return res
+}
+
+func (p Platform) rankAlias(res *Ranking, category, expected string, matched []alias, score int) {
+	if len(matched) == 0 {
+		return
+	}
+
+	var mismatched []string
+
+	for _, m := range matched {
+		if m.Value == expected {
+			res.Score += score
+			res.Reasons = append(res.Reasons, fmt.Sprintf("%s %s (%s)", category, m.Value, m.Name))
+
+			return
+		}
+
+		mismatched = append(mismatched, m.Value)
+	}
+
+	res.Compatible = false
+	res.Reasons = append(res.Reasons, fmt.Sprintf("%s %s", category, strings.Join(mismatched, ", ")))
+}
+
+// RankAll ranks the names from best to worst match.
+func (p Platform) RankAll(names []string) []Ranking {
+	var res []Ranking
+
+	for _, name := range names {
+		res = append(res, p.Rank(name))
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Compatible != res[j].Compatible {
+			return res[i].Compatible
+		} else if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// Best returns the compatible rankings with the highest score. Multiple results indicate an ambiguous match.
+func Best(rankings []Ranking) []Ranking {
+	var res []Ranking
+
+	for _, ranking := range rankings {
+		if !ranking.Compatible {
+			continue
+		} else if len(res) > 0 && ranking.Score < res[0].Score {
+			break
+		}
+
+		res = append(res, ranking)
+	}
+
+	return res
+}
+
+func matchExtension(name string, extensions []string) (string, bool) {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return ext, true
+		}
+	}
+
+	return "", false
+}
+
+var aliasBoundaryRegexps = map[string]*regexp.Regexp{}
+
+func init() {
+	for _, aliases := range [][]alias{osAliases, archAliases, libcAliases} {
+		for _, a := range aliases {
+			aliasBoundaryRegexps[a.Name] = newAliasRegexp(a.Name)
+		}
+	}
+
+	for _, name := range universalArchAliases {
+		aliasBoundaryRegexps[name] = newAliasRegexp(name)
+	}
+}
+
+func newAliasRegexp(name string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(?:^|[^a-z0-9])(%s)(?:$|[^a-z0-9])`, regexp.QuoteMeta(name)))
+}
+
+// findAlias returns the offset of name when it is not surrounded by other letters or numbers, or -1.
+func findAlias(s, name string) int {
+	loc := aliasBoundaryRegexps[name].FindStringSubmatchIndex(s)
+	if loc == nil {
+		return -1
+	}
+
+	return loc[2]
+}
+
+// matchAliases finds aliases in the order they appear in s, preferring longer aliases so their parts are not also
+// matched (e.g. x86_64 and x86).
+func matchAliases(s string, aliases []alias) []alias {
+	sorted := append([]alias{}, aliases...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Name) > len(sorted[j].Name)
+	})
+
+	type found struct {
+		alias
+		offset int
+	}
+
+	var matches []found
+
+	for _, a := range sorted {
+		for {
+			offset := findAlias(s, a.Name)
+			if offset < 0 {
+				break
+			}
+
+			matches = append(matches, found{alias: a, offset: offset})
+			s = s[:offset] + strings.Repeat("-", len(a.Name)) + s[offset+len(a.Name):]
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].offset < matches[j].offset
+	})
+
+	var res []alias
+
+	for _, m := range matches {
+		res = append(res, m.alias)
+	}
+
+	return res
+}
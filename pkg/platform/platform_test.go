@@ -0,0 +1,103 @@
+package platform_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/dpb587/gget/pkg/platform"
+)
+
+var _ = Describe("Platform", func() {
+	best := func(p Platform, names ...string) []string {
+		var res []string
+
+		for _, ranking := range Best(p.RankAll(names)) {
+			res = append(res, ranking.Name)
+		}
+
+		return res
+	}
+
+	linuxAMD64 := Platform{OS: "linux", Arch: "amd64"}
+
+	It("matches common naming conventions", func() {
+		for _, name := range []string{
+			"tool-1.0.0-linux-amd64",
+			"tool_1.0.0_Linux_x86_64.tar.gz",
+			"tool-linux64",
+			"tool-1.0.0-x86_64-unknown-linux-musl.tar.gz",
+			"tool_linux_64bit.zip",
+		} {
+			Expect(linuxAMD64.Rank(name).Compatible).To(BeTrue(), name)
+		}
+
+		for _, name := range []string{
+			"tool-1.0.0-linux-386",
+			"tool-1.0.0-linux-i686",
+			"tool-1.0.0-darwin-amd64",
+			"tool_1.0.0_macOS_x86_64.tar.gz",
+			"tool-1.0.0-aarch64-unknown-linux-gnu.tar.gz",
+			"tool-windows-amd64.exe",
+			"tool.exe",
+			"checksums.txt",
+			"tool-linux-amd64.sha256",
+		} {
+			Expect(linuxAMD64.Rank(name).Compatible).To(BeFalse(), name)
+		}
+	})
+
+	It("picks the best asset of a release", func() {
+		names := []string{
+			"bosh-cli-6.4.1-darwin-amd64",
+			"bosh-cli-6.4.1-linux-amd64",
+			"bosh-cli-6.4.1-windows-amd64.exe",
+			"checksums.txt",
+		}
+
+		Expect(best(linuxAMD64, names...)).To(Equal([]string{"bosh-cli-6.4.1-linux-amd64"}))
+		Expect(best(Platform{OS: "darwin", Arch: "amd64"}, names...)).To(Equal([]string{"bosh-cli-6.4.1-darwin-amd64"}))
+		Expect(best(Platform{OS: "windows", Arch: "amd64"}, names...)).To(Equal([]string{"bosh-cli-6.4.1-windows-amd64.exe"}))
+	})
+
+	It("prefers binaries, conventional archives, and static libc", func() {
+		Expect(best(
+			linuxAMD64,
+			"tool_1.0.0_amd64.deb",
+			"tool_1.0.0_linux_amd64.tar.gz",
+			"tool_1.0.0_linux_amd64.zip",
+		)).To(Equal([]string{"tool_1.0.0_linux_amd64.tar.gz"}))
+
+		Expect(best(
+			linuxAMD64,
+			"tool-x86_64-unknown-linux-gnu.tar.gz",
+			"tool-x86_64-unknown-linux-musl.tar.gz",
+		)).To(Equal([]string{"tool-x86_64-unknown-linux-musl.tar.gz"}))
+
+		Expect(best(
+			Platform{OS: "windows", Arch: "amd64"},
+			"tool_windows_amd64.tar.gz",
+			"tool_windows_amd64.zip",
+		)).To(Equal([]string{"tool_windows_amd64.zip"}))
+	})
+
+	It("accepts universal builds", func() {
+		Expect(best(
+			Platform{OS: "darwin", Arch: "arm64"},
+			"tool_linux_arm64.tar.gz",
+			"tool_macos_universal.tar.gz",
+		)).To(Equal([]string{"tool_macos_universal.tar.gz"}))
+	})
+
+	It("reports ambiguous matches", func() {
+		Expect(best(
+			Platform{OS: "linux", Arch: "arm"},
+			"tool-linux-armv6",
+			"tool-linux-armv7",
+		)).To(HaveLen(2))
+	})
+
+	It("explains the ranking", func() {
+		Expect(linuxAMD64.Rank("tool_Linux_x86_64.tar.gz").String()).To(Equal("tool_Linux_x86_64.tar.gz (score 22: archive .tar.gz; os linux (linux); arch amd64 (x86_64))"))
+		Expect(linuxAMD64.Rank("tool_darwin_amd64").String()).To(Equal("tool_darwin_amd64 (incompatible: binary; os darwin; arch amd64 (amd64))"))
+	})
+})
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+type Error struct {
+	Host  string
+	Reset time.Time
+
+	// MaxWait is set when waiting was allowed, but the reset is later.
+	MaxWait time.Duration
+}
+
+var _ error = &Error{}
+
+func (err *Error) Error() string {
+	var reset = "an unknown time"
+
+	if !err.Reset.IsZero() {
+		reset = err.Reset.Local().Format(time.RFC3339)
+	}
+
+	if err.MaxWait > 0 {
+		return fmt.Sprintf("rate limit exceeded for %s until %s (longer than the maximum wait of %s)", err.Host, reset, err.MaxWait)
+	}
+
+	return fmt.Sprintf("rate limit exceeded for %s until %s (authenticate with GITHUB_TOKEN, GITLAB_TOKEN, or netrc for higher limits; or use --rate-limit=wait)", err.Host, reset)
+}
@@ -0,0 +1,20 @@
+package ratelimit
+
+import "fmt"
+
+type Mode string
+
+// FailMode returns an error as soon as the rate limit is known to be exceeded.
+const FailMode Mode = "fail"
+
+// WaitMode pauses requests until the rate limit has been reset.
+const WaitMode Mode = "wait"
+
+func ParseMode(in string) (Mode, error) {
+	switch Mode(in) {
+	case FailMode, WaitMode:
+		return Mode(in), nil
+	}
+
+	return "", fmt.Errorf("unsupported rate limit mode: %s", in)
+}
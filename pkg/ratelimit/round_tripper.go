@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Tracker remembers servers whose rate limits have been exhausted. It is intended to be shared across clients.
+type Tracker struct {
+	log  logrus.FieldLogger
+	mode Mode
+
+	// maxWait is the longest wait for a reset in WaitMode (if positive); later resets fail instead.
+	maxWait time.Duration
+
+	resets  map[string]time.Time
+	resetsM sync.Mutex
+}
+
+func NewTracker(log logrus.FieldLogger, mode Mode, maxWait time.Duration) *Tracker {
+	return &Tracker{
+		log:     log,
+		mode:    mode,
+		maxWait: maxWait,
+		resets:  map[string]time.Time{},
+	}
+}
+
+func (t *Tracker) NewRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return roundTripper{
+		tracker: t,
+		rt:      rt,
+	}
+}
+
+func (t *Tracker) await(ctx context.Context, host string) error {
+	t.resetsM.Lock()
+	reset, found := t.resets[host]
+	t.resetsM.Unlock()
+
+	if !found {
+		return nil
+	}
+
+	if !reset.IsZero() && !time.Now().Before(reset) {
+		t.clear(host)
+
+		return nil
+	} else if t.mode != WaitMode || reset.IsZero() {
+		return &Error{
+			Host:  host,
+			Reset: reset,
+		}
+	} else if t.maxWait > 0 && time.Until(reset) > t.maxWait {
+		return &Error{
+			Host:    host,
+			Reset:   reset,
+			MaxWait: t.maxWait,
+		}
+	}
+
+	t.log.Warnf("rate limit exceeded for %s: waiting until %s", host, reset.Local().Format(time.RFC3339))
+
+	timer := time.NewTimer(time.Until(reset))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	t.clear(host)
+
+	return nil
+}
+
+func (t *Tracker) exhaust(host string, reset time.Time) {
+	t.resetsM.Lock()
+	defer t.resetsM.Unlock()
+
+	t.resets[host] = reset
+}
+
+func (t *Tracker) clear(host string) {
+	t.resetsM.Lock()
+	defer t.resetsM.Unlock()
+
+	delete(t.resets, host)
+}
+
+type roundTripper struct {
+	tracker *Tracker
+	rt      http.RoundTripper
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	rewindable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+
+		if attempt > 1 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		if err := rt.tracker.await(ctx, host); err != nil {
+			return nil, err
+		}
+
+		res, err := rt.rt.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining, reset, found := ParseHeaders(res.Header)
+		if !found {
+			return res, nil
+		} else if remaining > 0 {
+			rt.tracker.clear(host)
+
+			return res, nil
+		}
+
+		rt.tracker.exhaust(host, reset)
+
+		if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+			// the last request of the quota was still allowed
+			return res, nil
+		} else if !rewindable {
+			return res, nil
+		}
+
+		io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+		res.Body.Close()
+	}
+}
+
+// ParseHeaders supports both GitHub (X-RateLimit-*) and GitLab (RateLimit-*) conventions.
+func ParseHeaders(header http.Header) (int, time.Time, bool) {
+	remainingRaw := header.Get("x-ratelimit-remaining")
+	resetRaw := header.Get("x-ratelimit-reset")
+
+	if remainingRaw == "" {
+		remainingRaw = header.Get("ratelimit-remaining")
+		resetRaw = header.Get("ratelimit-reset")
+	}
+
+	if remainingRaw == "" {
+		return 0, time.Time{}, false
+	}
+
+	remaining, err := strconv.Atoi(remainingRaw)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+
+	var reset time.Time
+
+	if resetUnix, err := strconv.ParseInt(resetRaw, 10, 64); err == nil {
+		reset = time.Unix(resetUnix, 0)
+	} else if retryAfter, err := strconv.Atoi(header.Get("retry-after")); err == nil {
+		reset = time.Now().Add(time.Duration(retryAfter) * time.Second)
+	}
+
+	return remaining, reset, true
+}
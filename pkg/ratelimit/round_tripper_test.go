@@ -0,0 +1,93 @@
+package ratelimit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/dpb587/gget/pkg/ratelimit"
+)
+
+var _ = Describe("Tracker", func() {
+	var server *httptest.Server
+	var requests int
+	var resetAfter time.Duration
+
+	BeforeEach(func() {
+		requests = 0
+		resetAfter = time.Second
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			if requests == 1 {
+				w.Header().Set("x-ratelimit-remaining", "0")
+				w.Header().Set("x-ratelimit-reset", fmt.Sprintf("%d", time.Now().Add(resetAfter).Unix()))
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			w.Header().Set("x-ratelimit-remaining", "59")
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(mode Mode) *http.Client {
+		log, _ := test.NewNullLogger()
+
+		return &http.Client{
+			Transport: NewTracker(log, mode, time.Minute).NewRoundTripper(http.DefaultTransport),
+		}
+	}
+
+	It("fails when exceeded", func() {
+		_, err := newClient(FailMode).Get(server.URL)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("rate limit exceeded"))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("waits for reset when exceeded", func() {
+		res, err := newClient(WaitMode).Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(Equal(2))
+	})
+
+	It("fails when the reset is later than the maximum wait", func() {
+		resetAfter = time.Hour
+
+		_, err := newClient(WaitMode).Get(server.URL)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("longer than the maximum wait of 1m0s"))
+		Expect(requests).To(Equal(1))
+	})
+})
+
+var _ = Describe("ParseHeaders", func() {
+	It("parses gitlab headers", func() {
+		header := http.Header{}
+		header.Set("RateLimit-Remaining", "3")
+		header.Set("RateLimit-Reset", "1600000000")
+
+		remaining, reset, found := ParseHeaders(header)
+		Expect(found).To(BeTrue())
+		Expect(remaining).To(Equal(3))
+		Expect(reset.Unix()).To(Equal(int64(1600000000)))
+	})
+
+	It("ignores missing headers", func() {
+		_, _, found := ParseHeaders(http.Header{})
+		Expect(found).To(BeFalse())
+	})
+})
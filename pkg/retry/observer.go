@@ -0,0 +1,21 @@
+package retry
+
+import "context"
+
+// Observer is notified before a failed operation is attempted again.
+type Observer func(attempt int, err error)
+
+type observerKey struct{}
+
+func WithObserver(ctx context.Context, observer Observer) context.Context {
+	return context.WithValue(ctx, observerKey{}, observer)
+}
+
+func Notify(ctx context.Context, attempt int, err error) {
+	observer, ok := ctx.Value(observerKey{}).(Observer)
+	if !ok {
+		return
+	}
+
+	observer(attempt, err)
+}
@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+type Policy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	// MaxWait is the longest delay requested by a server (e.g. Retry-After) which is waited for (if positive).
+	MaxWait time.Duration
+}
+
+func NewPolicy(retries int) Policy {
+	return Policy{
+		MaxAttempts:  retries + 1,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+	}
+}
+
+// AllowsAttempt returns true if the attempt (starting from 1) is within the policy.
+func (p Policy) AllowsAttempt(attempt int) bool {
+	if p.MaxAttempts < 1 {
+		return attempt == 1
+	}
+
+	return attempt <= p.MaxAttempts
+}
+
+// Delay returns an exponential backoff duration with full jitter for the attempt which just failed.
+func (p Policy) Delay(attempt int) time.Duration {
+	if p.InitialDelay <= 0 {
+		return 0
+	}
+
+	delay := p.InitialDelay
+
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			delay = p.MaxDelay
+
+			break
+		}
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// Wait blocks for the delay of the attempt, or until the context is done. It fails without waiting if the minimum delay
+// is longer than the maximum wait.
+func (p Policy) Wait(ctx context.Context, attempt int, minDelay time.Duration) error {
+	if p.MaxWait > 0 && minDelay > p.MaxWait {
+		return fmt.Errorf("requested retry delay of %s exceeds the maximum wait of %s", minDelay.Round(time.Second), p.MaxWait)
+	}
+
+	delay := p.Delay(attempt)
+	if minDelay > delay {
+		delay = minDelay
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
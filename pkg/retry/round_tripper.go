@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dpb587/gget/pkg/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+type RoundTripper struct {
+	log    logrus.FieldLogger
+	rt     http.RoundTripper
+	policy Policy
+}
+
+var _ http.RoundTripper = RoundTripper{}
+
+func NewRoundTripper(log logrus.FieldLogger, rt http.RoundTripper, policy Policy) http.RoundTripper {
+	return RoundTripper{
+		log:    log,
+		rt:     rt,
+		policy: policy,
+	}
+}
+
+func (rt RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	rewindable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+
+		if attempt > 1 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		res, err := rt.rt.RoundTrip(attemptReq)
+
+		retryErr, retryAfter := rt.checkRetryable(req, res, err)
+		if retryErr == nil || !rewindable || !rt.policy.AllowsAttempt(attempt+1) {
+			return res, err
+		}
+
+		if res != nil {
+			// drain for connection reuse
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+
+		rt.log.Warnf("http: %s %s (attempt %d failed: %s)", req.Method, req.URL.String(), attempt, retryErr)
+
+		Notify(ctx, attempt+1, retryErr)
+
+		if err := rt.policy.Wait(ctx, attempt, retryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (rt RoundTripper) checkRetryable(req *http.Request, res *http.Response, err error) (error, time.Duration) {
+	if err != nil {
+		if req.Context().Err() != nil {
+			// caller gave up; not transient
+			return nil, 0
+		}
+
+		return err, 0
+	}
+
+	if !IsRetryableStatus(res.StatusCode) {
+		return nil, 0
+	} else if remaining, _, found := ratelimit.ParseHeaders(res.Header); found && remaining == 0 {
+		// an exhausted quota is not transient
+		return nil, 0
+	}
+
+	return fmt.Errorf("status %s", res.Status), ParseRetryAfter(res.Header.Get("retry-after"))
+}
+
+// IsRetryableStatus returns true for server errors and throttling which are expected to be transient.
+func IsRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// ParseRetryAfter supports both delay-seconds and HTTP-date values.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
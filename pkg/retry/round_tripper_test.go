@@ -0,0 +1,113 @@
+package retry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/dpb587/gget/pkg/retry"
+)
+
+var _ = Describe("RoundTripper", func() {
+	var server *httptest.Server
+	var responses []int
+	var requests int
+	var retryAfter string
+	var log *logrus.Logger
+
+	BeforeEach(func() {
+		requests = 0
+		retryAfter = ""
+		log, _ = test.NewNullLogger()
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if retryAfter != "" {
+				w.Header().Set("retry-after", retryAfter)
+			}
+
+			w.WriteHeader(responses[requests])
+			requests++
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(retries int) *http.Client {
+		policy := NewPolicy(retries)
+		policy.InitialDelay = time.Millisecond
+		policy.MaxWait = time.Minute
+
+		return &http.Client{
+			Transport: NewRoundTripper(log, http.DefaultTransport, policy),
+		}
+	}
+
+	It("retries transient errors", func() {
+		responses = []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK}
+
+		var notified []int
+
+		ctx := WithObserver(context.Background(), func(attempt int, _ error) {
+			notified = append(notified, attempt)
+		})
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+		res, err := newClient(3).Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(Equal(3))
+		Expect(notified).To(Equal([]int{2, 3}))
+	})
+
+	It("stops after max attempts", func() {
+		responses = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}
+
+		res, err := newClient(1).Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(requests).To(Equal(2))
+	})
+
+	It("fails when the requested delay is longer than the maximum wait", func() {
+		responses = []int{http.StatusServiceUnavailable, http.StatusOK}
+		retryAfter = "3600"
+
+		_, err := newClient(3).Get(server.URL)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("requested retry delay of 1h0m0s exceeds the maximum wait of 1m0s"))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("does not retry client errors", func() {
+		responses = []int{http.StatusNotFound, http.StatusOK}
+
+		res, err := newClient(3).Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(requests).To(Equal(1))
+	})
+})
+
+var _ = Describe("ParseRetryAfter", func() {
+	It("parses seconds", func() {
+		Expect(ParseRetryAfter("120")).To(Equal(120 * time.Second))
+	})
+
+	It("parses dates", func() {
+		delay := ParseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		Expect(delay).To(BeNumerically("~", time.Minute, 2*time.Second))
+	})
+
+	It("ignores invalid values", func() {
+		Expect(ParseRetryAfter("soon")).To(Equal(time.Duration(0)))
+	})
+})
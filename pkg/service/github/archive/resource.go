@@ -5,30 +5,38 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/google/go-github/v29/github"
 	"github.com/pkg/errors"
 )
 
 type Resource struct {
-	client   *github.Client
-	ref      service.Ref
-	target   string
-	filename string
+	client         *github.Client
+	downloadClient *http.Client
+	ref            service.Ref
+	target         string
+	filename       string
+	modTime        func(context.Context) (time.Time, error)
 }
 
 var _ service.ResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ArchiveURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
 
-func NewResource(client *github.Client, ref service.Ref, target, filename string) *Resource {
+func NewResource(client *github.Client, downloadClient *http.Client, ref service.Ref, target, filename string, modTime func(context.Context) (time.Time, error)) *Resource {
 	return &Resource{
-		client:   client,
-		ref:      ref,
-		target:   target,
-		filename: filename,
+		client:         client,
+		downloadClient: downloadClient,
+		ref:            ref,
+		target:         target,
+		filename:       filename,
+		modTime:        modTime,
 	}
 }
 
@@ -40,40 +48,81 @@ func (r *Resource) GetSize() int64 {
 	return 0
 }
 
+func (r *Resource) GetDownloadURL() string {
+	format, err := r.archiveFormat()
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%srepos/%s/%s/%s/%s", r.client.BaseURL, r.ref.Owner, r.ref.Repository, format, r.target)
+}
+
+func (r *Resource) GetArchiveURL() string {
+	format, err := r.archiveFormat()
+	if err != nil {
+		return ""
+	}
+
+	ext := "tar.gz"
+	if format == "zipball" {
+		ext = "zip"
+	}
+
+	return fmt.Sprintf("https://%s/%s/%s/archive/%s.%s", r.ref.Server, r.ref.Owner, r.ref.Repository, r.target, ext)
+}
+
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	return r.modTime(ctx)
+}
+
 func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
-	var archiveLink *url.URL
-	var err error
+	res, err := r.OpenRange(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
 
-	ext := filepath.Ext(r.filename)
-	if ext == ".gz" {
-		ext = fmt.Sprintf("%s%s", filepath.Ext(strings.TrimSuffix(r.filename, ext)), ext)
+	return res, nil
+}
+
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	format, err := r.archiveFormat()
+	if err != nil {
+		return nil, err
 	}
 
-	switch ext {
-	case ".tar.gz", ".tgz":
-		archiveLink, _, err = r.client.Repositories.GetArchiveLink(ctx, r.ref.Owner, r.ref.Repository, github.Tarball, &github.RepositoryContentGetOptions{
-			Ref: r.target,
-		}, false)
-	case ".zip":
-		archiveLink, _, err = r.client.Repositories.GetArchiveLink(ctx, r.ref.Owner, r.ref.Repository, github.Zipball, &github.RepositoryContentGetOptions{
-			Ref: r.target,
-		}, false)
-	default:
-		return nil, fmt.Errorf("unrecognized extension: %s", ext)
+	archiveFormat := github.Tarball
+	if format == "zipball" {
+		archiveFormat = github.Zipball
 	}
 
+	archiveLink, _, err := r.client.Repositories.GetArchiveLink(ctx, r.ref.Owner, r.ref.Repository, archiveFormat, &github.RepositoryContentGetOptions{
+		Ref: r.target,
+	}, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting archive url")
 	}
 
-	res, err := http.DefaultClient.Get(archiveLink.String())
+	res, err := httputil.GetRange(ctx, r.downloadClient, archiveLink.String(), offset, length, validator)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting download url")
+		return nil, errors.Wrapf(err, "getting download url %s", archiveLink)
 	}
 
-	if res.StatusCode != 200 {
-		return nil, errors.Wrapf(fmt.Errorf("expected status 200: got %d", res.StatusCode), "getting download url %s", archiveLink)
+	return res, nil
+}
+
+// archiveFormat returns the API name of the format (i.e. tarball, zipball).
+func (r *Resource) archiveFormat() (string, error) {
+	ext := filepath.Ext(r.filename)
+	if ext == ".gz" {
+		ext = fmt.Sprintf("%s%s", filepath.Ext(strings.TrimSuffix(r.filename, ext)), ext)
+	}
+
+	switch ext {
+	case ".tar.gz", ".tgz":
+		return "tarball", nil
+	case ".zip":
+		return "zipball", nil
 	}
 
-	return res.Body, nil
+	return "", fmt.Errorf("unrecognized extension: %s", ext)
 }
@@ -2,11 +2,12 @@ package asset
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/google/go-github/v29/github"
 	"github.com/pkg/errors"
@@ -14,6 +15,7 @@ import (
 
 type Resource struct {
 	client            *github.Client
+	downloadClient    *http.Client
 	releaseOwner      string
 	releaseRepository string
 	checksumManager   checksum.Manager
@@ -22,10 +24,13 @@ type Resource struct {
 
 var _ service.ResolvedResource = &Resource{}
 var _ service.ChecksumSupportedResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
 
-func NewResource(client *github.Client, releaseOwner, releaseRepository string, asset github.ReleaseAsset, checksumManager checksum.Manager) *Resource {
+func NewResource(client *github.Client, downloadClient *http.Client, releaseOwner, releaseRepository string, asset github.ReleaseAsset, checksumManager checksum.Manager) *Resource {
 	return &Resource{
 		client:            client,
+		downloadClient:    downloadClient,
 		releaseOwner:      releaseOwner,
 		releaseRepository: releaseRepository,
 		asset:             asset,
@@ -41,6 +46,14 @@ func (r *Resource) GetSize() int64 {
 	return int64(r.asset.GetSize())
 }
 
+func (r *Resource) GetDownloadURL() string {
+	return r.asset.GetBrowserDownloadURL()
+}
+
+func (r *Resource) GetModTime(_ context.Context) (time.Time, error) {
+	return r.asset.GetUpdatedAt().Time, nil
+}
+
 func (r *Resource) GetChecksums(ctx context.Context, algos checksum.AlgorithmList) (checksum.ChecksumList, error) {
 	if r.checksumManager == nil {
 		return nil, nil
@@ -57,23 +70,28 @@ func (r *Resource) GetChecksums(ctx context.Context, algos checksum.AlgorithmLis
 }
 
 func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
+	res, err := r.OpenRange(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
 	remoteHandle, redirectURL, err := r.client.Repositories.DownloadReleaseAsset(ctx, r.releaseOwner, r.releaseRepository, r.asset.GetID(), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "requesting asset")
 	}
 
-	if redirectURL != "" {
-		res, err := http.DefaultClient.Get(redirectURL)
-		if err != nil {
-			return nil, errors.Wrapf(err, "getting download url %s", redirectURL)
-		}
-
-		if res.StatusCode != 200 {
-			return nil, errors.Wrapf(fmt.Errorf("expected status 200: got %d", res.StatusCode), "getting download url %s", redirectURL)
-		}
+	if redirectURL == "" {
+		return &httputil.RangeReader{ReadCloser: remoteHandle}, nil
+	}
 
-		remoteHandle = res.Body
+	res, err := httputil.GetRange(ctx, r.downloadClient, redirectURL, offset, length, validator)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting download url %s", redirectURL)
 	}
 
-	return remoteHandle, nil
+	return res, nil
 }
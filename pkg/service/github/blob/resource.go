@@ -1,31 +1,43 @@
 package blob
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"net/http"
+	"time"
 
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/google/go-github/v29/github"
 	"github.com/pkg/errors"
 )
 
+// rawHeader requests the blob content rather than its JSON (base64-encoded) representation.
+var rawHeader = http.Header{"Accept": []string{"application/vnd.github.v3.raw"}}
+
 type Resource struct {
 	client            *github.Client
+	downloadClient    *http.Client
 	releaseOwner      string
 	releaseRepository string
 	asset             github.TreeEntry
+	modTime           func(context.Context) (time.Time, error)
 }
 
 var _ service.ResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
+var _ service.TreeModeResolvedResource = &Resource{}
 
-func NewResource(client *github.Client, releaseOwner, releaseRepository string, asset github.TreeEntry) *Resource {
+func NewResource(client *github.Client, downloadClient *http.Client, releaseOwner, releaseRepository string, asset github.TreeEntry, modTime func(context.Context) (time.Time, error)) *Resource {
 	return &Resource{
 		client:            client,
+		downloadClient:    downloadClient,
 		releaseOwner:      releaseOwner,
 		releaseRepository: releaseRepository,
 		asset:             asset,
+		modTime:           modTime,
 	}
 }
 
@@ -37,12 +49,32 @@ func (r *Resource) GetSize() int64 {
 	return int64(r.asset.GetSize())
 }
 
+func (r *Resource) GetDownloadURL() string {
+	return fmt.Sprintf("%srepos/%s/%s/git/blobs/%s", r.client.BaseURL, r.releaseOwner, r.releaseRepository, r.asset.GetSHA())
+}
+
+func (r *Resource) GetTreeMode() string {
+	return r.asset.GetMode()
+}
+
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	return r.modTime(ctx)
+}
+
 func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
-	// TODO switch to stream?
-	buf, _, err := r.client.Git.GetBlobRaw(ctx, r.releaseOwner, r.releaseRepository, r.asset.GetSHA())
+	res, err := r.OpenRange(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	res, err := httputil.GetRangeWithHeader(ctx, r.downloadClient, r.GetDownloadURL(), rawHeader, offset, length, validator)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting blob")
 	}
 
-	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	return res, nil
 }
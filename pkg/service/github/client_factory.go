@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/bgentry/go-netrc/netrc"
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/google/go-github/v29/github"
 	"github.com/mitchellh/go-homedir"
@@ -19,31 +20,28 @@ import (
 type roundTripTransformer func(http.RoundTripper) http.RoundTripper
 
 type ClientFactory struct {
-	log               *logrus.Logger
-	httpClientFactory func() *http.Client
+	log                       *logrus.Logger
+	httpClientFactory         func() *http.Client
+	httpDownloadClientFactory func() *http.Client
 }
 
-func NewClientFactory(log *logrus.Logger, httpClientFactory func() *http.Client) *ClientFactory {
+func NewClientFactory(log *logrus.Logger, httpClientFactory, httpDownloadClientFactory func() *http.Client) *ClientFactory {
 	return &ClientFactory{
-		log:               log,
-		httpClientFactory: httpClientFactory,
+		log:                       log,
+		httpClientFactory:         httpClientFactory,
+		httpDownloadClientFactory: httpDownloadClientFactory,
 	}
 }
 
-func (cf ClientFactory) Get(ctx context.Context, lookupRef service.LookupRef) (*github.Client, error) {
-	var tokenSource oauth2.TokenSource
-
-	if v := os.Getenv("GITHUB_TOKEN"); v != "" {
-		cf.log.Infof("found authentication for %s: env GITHUB_TOKEN", lookupRef.Ref.Server)
-
-		tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: v})
-	} else {
-		var err error
+// GetDownloadClient returns a client for downloading files outside of the API.
+func (cf ClientFactory) GetDownloadClient() *http.Client {
+	return cf.httpDownloadClientFactory()
+}
 
-		tokenSource, err = cf.loadNetrc(ctx, lookupRef)
-		if err != nil {
-			return nil, errors.Wrap(err, "loading auth from netrc")
-		}
+func (cf ClientFactory) Get(ctx context.Context, lookupRef service.LookupRef) (*github.Client, error) {
+	tokenSource, err := cf.getTokenSource(ctx, lookupRef, true)
+	if err != nil {
+		return nil, err
 	}
 
 	httpClient := cf.httpClientFactory()
@@ -70,7 +68,90 @@ func (cf ClientFactory) Get(ctx context.Context, lookupRef service.LookupRef) (*
 	return c, nil
 }
 
-func (cf ClientFactory) loadNetrc(ctx context.Context, lookupRef service.LookupRef) (oauth2.TokenSource, error) {
+// GetAPIDownloadClient returns a client for downloading content from the API (e.g. raw blobs). It uses the same
+// authentication as the API client, but not its timeout or response cache since the content may be large.
+func (cf ClientFactory) GetAPIDownloadClient(ctx context.Context, lookupRef service.LookupRef) (*http.Client, error) {
+	tokenSource, err := cf.getTokenSource(ctx, lookupRef, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := cf.httpDownloadClientFactory()
+
+	if tokenSource == nil {
+		return httpClient, nil
+	}
+
+	token, err := tokenSource.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting token")
+	}
+
+	host := lookupRef.Ref.Server
+	if host == "github.com" {
+		host = "api.github.com"
+	}
+
+	header := http.Header{}
+	token.SetAuthHeader(&http.Request{Header: header})
+
+	httpClient.Transport = &httputil.HostHeaderRoundTripper{
+		Base:   httpClient.Transport,
+		Host:   host,
+		Header: header,
+	}
+
+	return httpClient, nil
+}
+
+// GetLFSClient returns a client for the LFS server of repositories which uses the token as basic authentication.
+func (cf ClientFactory) GetLFSClient(ctx context.Context, lookupRef service.LookupRef) (*http.Client, error) {
+	tokenSource, err := cf.getTokenSource(ctx, lookupRef, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := cf.httpDownloadClientFactory()
+
+	if tokenSource == nil {
+		return httpClient, nil
+	}
+
+	token, err := tokenSource.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting token")
+	}
+
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth("x-access-token", token.AccessToken)
+
+	httpClient.Transport = &httputil.HostHeaderRoundTripper{
+		Base:   httpClient.Transport,
+		Host:   lookupRef.Ref.Server,
+		Header: req.Header,
+	}
+
+	return httpClient, nil
+}
+
+func (cf ClientFactory) getTokenSource(ctx context.Context, lookupRef service.LookupRef, logFound bool) (oauth2.TokenSource, error) {
+	if v := os.Getenv("GITHUB_TOKEN"); v != "" {
+		if logFound {
+			cf.log.Infof("found authentication for %s: env GITHUB_TOKEN", lookupRef.Ref.Server)
+		}
+
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: v}), nil
+	}
+
+	tokenSource, err := cf.loadNetrc(ctx, lookupRef, logFound)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading auth from netrc")
+	}
+
+	return tokenSource, nil
+}
+
+func (cf ClientFactory) loadNetrc(ctx context.Context, lookupRef service.LookupRef, logFound bool) (oauth2.TokenSource, error) {
 	netrcPath := os.Getenv("NETRC")
 	if netrcPath == "" {
 		var err error
@@ -103,7 +184,9 @@ func (cf ClientFactory) loadNetrc(ctx context.Context, lookupRef service.LookupR
 		return nil, nil
 	}
 
-	cf.log.Infof("found authentication for %s: netrc %s", lookupRef.Ref.Server, netrcPath)
+	if logFound {
+		cf.log.Infof("found authentication for %s: netrc %s", lookupRef.Ref.Server, netrcPath)
+	}
 
 	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: machine.Password}), nil
 }
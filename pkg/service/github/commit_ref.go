@@ -3,8 +3,13 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/dpb587/gget/pkg/gitlfs"
+	"github.com/dpb587/gget/pkg/globutil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/service/github/archive"
 	"github.com/dpb587/gget/pkg/service/github/blob"
@@ -13,16 +18,23 @@ import (
 )
 
 type CommitRef struct {
-	client   *github.Client
-	ref      service.Ref
-	commit   string
-	metadata service.RefMetadata
+	client            *github.Client
+	downloadClient    *http.Client
+	apiDownloadClient *http.Client
+	lfsClient         *gitlfs.BatchClient
+	ref               service.Ref
+	commit            string
+	metadata          service.RefMetadata
 
 	archiveFileBase string
+
+	commitTime  time.Time
+	commitTimeM sync.Mutex
 }
 
 var _ service.ResolvedRef = &ReleaseRef{}
 var _ service.ResourceResolver = &CommitRef{}
+var _ service.SubmoduleResolver = &CommitRef{}
 
 func (r *CommitRef) CanonicalRef() service.Ref {
 	return r.ref
@@ -60,9 +72,11 @@ func (r *CommitRef) resolveArchiveResource(ctx context.Context, resource service
 			res,
 			archive.NewResource(
 				r.client,
+				r.downloadClient,
 				r.ref,
 				r.commit,
 				candidate,
+				r.getCommitTime,
 			),
 		)
 	}
@@ -80,12 +94,58 @@ func (r *CommitRef) resolveBlobResource(ctx context.Context, resource service.Re
 	}
 
 	for _, candidate := range tree.Entries {
-		if match, _ := filepath.Match(string(resource), candidate.GetPath()); !match {
+		if candidate.GetType() != "blob" {
+			continue
+		} else if match, _ := globutil.Match(string(resource), candidate.GetPath()); !match {
 			continue
 		}
 
-		res = append(res, blob.NewResource(r.client, r.ref.Owner, r.ref.Repository, candidate))
+		res = append(res, blob.NewResource(r.client, r.apiDownloadClient, r.ref.Owner, r.ref.Repository, candidate, r.getCommitTime))
+	}
+
+	res, err = gitlfs.Resolve(ctx, r.lfsClient, res)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolving lfs objects")
 	}
 
 	return res, nil
 }
+
+func (r *CommitRef) ResolveSubmodules(ctx context.Context) ([]service.Submodule, error) {
+	var res []service.Submodule
+
+	tree, _, err := r.client.Git.GetTree(ctx, r.ref.Owner, r.ref.Repository, r.commit, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting commit tree")
+	}
+
+	for _, candidate := range tree.Entries {
+		if candidate.GetType() != "commit" {
+			continue
+		}
+
+		res = append(res, service.Submodule{
+			Path:   candidate.GetPath(),
+			Commit: candidate.GetSHA(),
+		})
+	}
+
+	return res, nil
+}
+
+// getCommitTime is only requested when needed and then reused by all resources of the commit.
+func (r *CommitRef) getCommitTime(ctx context.Context) (time.Time, error) {
+	r.commitTimeM.Lock()
+	defer r.commitTimeM.Unlock()
+
+	if r.commitTime.IsZero() {
+		commit, _, err := r.client.Git.GetCommit(ctx, r.ref.Owner, r.ref.Repository, r.commit)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "getting commit")
+		}
+
+		r.commitTime = commit.GetCommitter().GetDate()
+	}
+
+	return r.commitTime, nil
+}
@@ -7,15 +7,19 @@ import (
 	"path"
 	"strings"
 
+	"github.com/dpb587/gget/pkg/gitlfs"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/google/go-github/v29/github"
 	"github.com/pkg/errors"
 )
 
 type refResolver struct {
-	client       *github.Client
-	lookupRef    service.LookupRef
-	canonicalRef service.Ref
+	client            *github.Client
+	downloadClient    *http.Client
+	apiDownloadClient *http.Client
+	lfsClient         *gitlfs.BatchClient
+	lookupRef         service.LookupRef
+	canonicalRef      service.Ref
 }
 
 func (rr *refResolver) resolveTagWithRelease(ctx context.Context, release *github.RepositoryRelease) (service.ResolvedRef, error) {
@@ -34,10 +38,13 @@ func (rr *refResolver) resolveTagWithRelease(ctx context.Context, release *githu
 
 func (rr *refResolver) resolveCommit(ctx context.Context, commitSHA string) (service.ResolvedRef, error) {
 	res := &CommitRef{
-		client:          rr.client,
-		ref:             rr.canonicalRef,
-		commit:          commitSHA,
-		archiveFileBase: fmt.Sprintf("%s-%s", rr.canonicalRef.Repository, commitSHA[0:9]),
+		client:            rr.client,
+		downloadClient:    rr.downloadClient,
+		apiDownloadClient: rr.apiDownloadClient,
+		lfsClient:         rr.lfsClient,
+		ref:               rr.canonicalRef,
+		commit:            commitSHA,
+		archiveFileBase:   fmt.Sprintf("%s-%s", rr.canonicalRef.Repository, commitSHA[0:9]),
 		metadata: service.RefMetadata{
 			{
 				Name:  "commit",
@@ -54,10 +61,13 @@ func (rr *refResolver) resolveHead(ctx context.Context, headRef *github.Referenc
 	commitSHA := headRef.Object.GetSHA()
 
 	res := &CommitRef{
-		client:          rr.client,
-		ref:             rr.canonicalRef,
-		commit:          commitSHA,
-		archiveFileBase: fmt.Sprintf("%s-%s", rr.canonicalRef.Repository, path.Base(branchName)),
+		client:            rr.client,
+		downloadClient:    rr.downloadClient,
+		apiDownloadClient: rr.apiDownloadClient,
+		lfsClient:         rr.lfsClient,
+		ref:               rr.canonicalRef,
+		commit:            commitSHA,
+		archiveFileBase:   fmt.Sprintf("%s-%s", rr.canonicalRef.Repository, path.Base(branchName)),
 		metadata: service.RefMetadata{
 			{
 				Name:  "branch",
@@ -97,10 +107,13 @@ func (rr *refResolver) resolveTag(ctx context.Context, tagRef *github.Reference,
 	commitSHA := tagObj.Object.GetSHA()
 
 	var res service.ResolvedRef = &CommitRef{
-		client:          rr.client,
-		ref:             rr.canonicalRef,
-		commit:          commitSHA,
-		archiveFileBase: fmt.Sprintf("%s-%s", rr.canonicalRef.Repository, tagName),
+		client:            rr.client,
+		downloadClient:    rr.downloadClient,
+		apiDownloadClient: rr.apiDownloadClient,
+		lfsClient:         rr.lfsClient,
+		ref:               rr.canonicalRef,
+		commit:            commitSHA,
+		archiveFileBase:   fmt.Sprintf("%s-%s", rr.canonicalRef.Repository, tagName),
 		metadata: service.RefMetadata{
 			{
 				Name:  "tag",
@@ -118,7 +131,7 @@ func (rr *refResolver) resolveTag(ctx context.Context, tagRef *github.Reference,
 	}
 
 	release, resp, err := rr.client.Repositories.GetReleaseByTag(ctx, rr.canonicalRef.Owner, rr.canonicalRef.Repository, tagName)
-	if resp.StatusCode == http.StatusNotFound {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
 		// oh well
 	} else if err != nil {
 		return nil, errors.Wrap(err, "getting release by tag")
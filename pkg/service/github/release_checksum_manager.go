@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"io"
+	"net/http"
 	"path/filepath"
 	"strings"
 
@@ -12,7 +13,7 @@ import (
 	"github.com/google/go-github/v29/github"
 )
 
-func NewReleaseChecksumManager(client *github.Client, releaseOwner, releaseRepository string, release *github.RepositoryRelease) checksum.Manager {
+func NewReleaseChecksumManager(client *github.Client, downloadClient *http.Client, releaseOwner, releaseRepository string, release *github.RepositoryRelease) checksum.Manager {
 	literalManager := checksum.NewInMemoryManager()
 	var deferredManagers []checksum.Manager
 
@@ -26,7 +27,7 @@ func NewReleaseChecksumManager(client *github.Client, releaseOwner, releaseRepos
 			continue
 		}
 
-		opener := newReleaseAssetChecksumOpener(client, releaseOwner, releaseRepository, releaseAsset)
+		opener := newReleaseAssetChecksumOpener(client, downloadClient, releaseOwner, releaseRepository, releaseAsset)
 
 		var expectedAlgos checksum.AlgorithmList
 
@@ -76,9 +77,9 @@ func checkReleaseAssetChecksumBehavior(releaseAsset github.ReleaseAsset) (checks
 	return "", "", false
 }
 
-func newReleaseAssetChecksumOpener(client *github.Client, releaseOwner, releaseRepository string, releaseAsset github.ReleaseAsset) func(context.Context) (io.ReadCloser, error) {
+func newReleaseAssetChecksumOpener(client *github.Client, downloadClient *http.Client, releaseOwner, releaseRepository string, releaseAsset github.ReleaseAsset) func(context.Context) (io.ReadCloser, error) {
 	return func(ctx context.Context) (io.ReadCloser, error) {
-		resource := asset.NewResource(client, releaseOwner, releaseRepository, releaseAsset, nil) // TODO pass shared checksum manager
+		resource := asset.NewResource(client, downloadClient, releaseOwner, releaseRepository, releaseAsset, nil) // TODO pass shared checksum manager
 
 		return resource.Open(ctx)
 	}
@@ -23,6 +23,7 @@ type ReleaseRef struct {
 
 var _ service.ResolvedRef = &ReleaseRef{}
 var _ service.ResourceResolver = &ReleaseRef{}
+var _ service.SubmoduleResolver = &ReleaseRef{}
 
 func (r *ReleaseRef) CanonicalRef() service.Ref {
 	return r.refResolver.canonicalRef
@@ -73,6 +74,20 @@ func (r *ReleaseRef) ResolveResource(ctx context.Context, resourceType service.R
 	return targetRef.ResolveResource(ctx, resourceType, resource)
 }
 
+func (r *ReleaseRef) ResolveSubmodules(ctx context.Context) ([]service.Submodule, error) {
+	targetRef, err := r.requireTargetRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sr, ok := targetRef.(service.SubmoduleResolver)
+	if !ok {
+		return nil, nil
+	}
+
+	return sr.ResolveSubmodules(ctx)
+}
+
 func (r *ReleaseRef) requireTargetRef(ctx context.Context) (service.ResolvedRef, error) {
 	if r.targetRef == nil {
 		ref, err := r.refResolver.resolveTagWithRelease(ctx, r.release)
@@ -96,7 +111,7 @@ func (r *ReleaseRef) resolveAssetResource(ctx context.Context, resource service.
 
 		res = append(
 			res,
-			asset.NewResource(r.refResolver.client, r.refResolver.canonicalRef.Owner, r.refResolver.canonicalRef.Repository, candidate, r.requireChecksumManager()),
+			asset.NewResource(r.refResolver.client, r.refResolver.downloadClient, r.refResolver.canonicalRef.Owner, r.refResolver.canonicalRef.Repository, candidate, r.requireChecksumManager()),
 		)
 	}
 
@@ -105,7 +120,7 @@ func (r *ReleaseRef) resolveAssetResource(ctx context.Context, resource service.
 
 func (r *ReleaseRef) requireChecksumManager() checksum.Manager {
 	if r.checksumManager == nil {
-		r.checksumManager = NewReleaseChecksumManager(r.refResolver.client, r.refResolver.canonicalRef.Owner, r.refResolver.canonicalRef.Repository, r.release)
+		r.checksumManager = NewReleaseChecksumManager(r.refResolver.client, r.refResolver.downloadClient, r.refResolver.canonicalRef.Owner, r.refResolver.canonicalRef.Repository, r.release)
 	}
 
 	return r.checksumManager
@@ -8,6 +8,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/dpb587/gget/pkg/gitlfs"
 	"github.com/dpb587/gget/pkg/gitutil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/google/go-github/v29/github"
@@ -67,10 +68,23 @@ func (s Service) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (s
 	ref := lookupRef.Ref
 	ref.Service = s.ServiceName()
 
+	apiDownloadClient, err := s.clientFactory.GetAPIDownloadClient(ctx, lookupRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "building download client")
+	}
+
+	lfsClient, err := s.clientFactory.GetLFSClient(ctx, lookupRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "building lfs client")
+	}
+
 	rr := &refResolver{
-		client:       client,
-		lookupRef:    lookupRef,
-		canonicalRef: ref,
+		client:            client,
+		downloadClient:    s.clientFactory.GetDownloadClient(),
+		apiDownloadClient: apiDownloadClient,
+		lfsClient:         gitlfs.NewBatchClient(lfsClient, fmt.Sprintf("https://%s/%s/%s.git/info/lfs", ref.Server, ref.Owner, ref.Repository)),
+		lookupRef:         lookupRef,
+		canonicalRef:      ref,
 	}
 
 	if ref.Ref == "" {
@@ -89,7 +103,7 @@ func (s Service) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (s
 
 	{ // tag
 		gitref, resp, err := client.Git.GetRefs(ctx, rr.canonicalRef.Owner, rr.canonicalRef.Repository, path.Join("tags", rr.canonicalRef.Ref))
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "attempting tag resolution")
@@ -100,7 +114,7 @@ func (s Service) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (s
 
 	{ // head
 		gitref, resp, err := client.Git.GetRefs(ctx, rr.canonicalRef.Owner, rr.canonicalRef.Repository, path.Join("heads", rr.canonicalRef.Ref))
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "attempting branch resolution")
@@ -112,7 +126,7 @@ func (s Service) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (s
 	if gitutil.PotentialCommitRE.MatchString(rr.canonicalRef.Ref) { // commit
 		// client.Git.GetCommit does not resolve partial commits
 		commitref, resp, err := client.Repositories.GetCommit(ctx, rr.canonicalRef.Owner, rr.canonicalRef.Repository, rr.canonicalRef.Ref)
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "attempting commit resolution")
@@ -1,11 +1,14 @@
 package archive
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/service/gitlab/gitlabutil"
 	"github.com/pkg/errors"
@@ -13,22 +16,29 @@ import (
 )
 
 type Resource struct {
-	client   *gitlab.Client
-	ref      service.Ref
-	target   string
-	filename string
-	format   string
+	client         *gitlab.Client
+	downloadClient *http.Client
+	ref            service.Ref
+	target         string
+	filename       string
+	format         string
+	modTime        func(context.Context) (time.Time, error)
 }
 
 var _ service.ResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ArchiveURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
 
-func NewResource(client *gitlab.Client, ref service.Ref, target, filename, format string) *Resource {
+func NewResource(client *gitlab.Client, downloadClient *http.Client, ref service.Ref, target, filename, format string, modTime func(context.Context) (time.Time, error)) *Resource {
 	return &Resource{
-		client:   client,
-		ref:      ref,
-		target:   target,
-		filename: filename,
-		format:   format,
+		client:         client,
+		downloadClient: downloadClient,
+		ref:            ref,
+		target:         target,
+		filename:       filename,
+		format:         format,
+		modTime:        modTime,
 	}
 }
 
@@ -40,15 +50,32 @@ func (r *Resource) GetSize() int64 {
 	return 0
 }
 
+func (r *Resource) GetDownloadURL() string {
+	return fmt.Sprintf("%sprojects/%s/repository/archive.%s?sha=%s", r.client.BaseURL(), url.PathEscape(gitlabutil.GetRepositoryID(r.ref)), r.format, url.QueryEscape(r.target))
+}
+
+func (r *Resource) GetArchiveURL() string {
+	return fmt.Sprintf("https://%s/%s/-/archive/%s/%s-%s.%s", r.ref.Server, gitlabutil.GetRepositoryID(r.ref), url.PathEscape(r.target), r.ref.Repository, url.PathEscape(r.target), r.format)
+}
+
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	return r.modTime(ctx)
+}
+
 func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
-	buf, _, err := r.client.Repositories.Archive(gitlabutil.GetRepositoryID(r.ref), &gitlab.ArchiveOptions{
-		Format: &r.format,
-		SHA:    &r.target,
-	})
+	res, err := r.OpenRange(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
 
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	res, err := httputil.GetRange(ctx, r.downloadClient, r.GetDownloadURL(), offset, length, validator)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting archive url")
+		return nil, errors.Wrap(err, "getting archive")
 	}
 
-	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	return res, nil
 }
@@ -2,11 +2,12 @@ package asset
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"time"
 
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/pkg/errors"
 	"github.com/xanzy/go-gitlab"
@@ -14,19 +15,25 @@ import (
 
 type Resource struct {
 	client            *gitlab.Client
+	downloadClient    *http.Client
 	releaseOwner      string
 	releaseRepository string
 	asset             *gitlab.ReleaseLink
+	releasedAt        time.Time
 }
 
 var _ service.ResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
 
-func NewResource(client *gitlab.Client, releaseOwner, releaseRepository string, asset *gitlab.ReleaseLink) *Resource {
+func NewResource(client *gitlab.Client, downloadClient *http.Client, releaseOwner, releaseRepository string, asset *gitlab.ReleaseLink, releasedAt time.Time) *Resource {
 	return &Resource{
 		client:            client,
+		downloadClient:    downloadClient,
 		releaseOwner:      releaseOwner,
 		releaseRepository: releaseRepository,
 		asset:             asset,
+		releasedAt:        releasedAt,
 	}
 }
 
@@ -34,19 +41,33 @@ func (r *Resource) GetName() string {
 	return path.Base(r.asset.URL)
 }
 
+func (r *Resource) GetDownloadURL() string {
+	return r.asset.URL
+}
+
 func (r *Resource) GetSize() int64 {
 	return 0
 }
 
+// GetModTime is when the release was published since links do not record when their content changed.
+func (r *Resource) GetModTime(_ context.Context) (time.Time, error) {
+	return r.releasedAt, nil
+}
+
 func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
-	res, err := http.DefaultClient.Get(r.asset.URL)
+	res, err := r.OpenRange(ctx, 0, 0, "")
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting %s", r.asset.URL)
+		return nil, err
 	}
 
-	if res.StatusCode != 200 {
-		return nil, errors.Wrapf(fmt.Errorf("expected status 200: got %d", res.StatusCode), "getting %s", r.asset.URL)
+	return res, nil
+}
+
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	res, err := httputil.GetRange(ctx, r.downloadClient, r.asset.URL, offset, length, validator)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s", r.asset.URL)
 	}
 
-	return res.Body, nil
+	return res, nil
 }
@@ -1,13 +1,14 @@
 package blob
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/service/gitlab/gitlabutil"
 	"github.com/pkg/errors"
@@ -15,62 +16,66 @@ import (
 )
 
 type Resource struct {
-	client *gitlab.Client
-	ref    service.Ref
-	target string
-	node   *gitlab.TreeNode
+	client         *gitlab.Client
+	downloadClient *http.Client
+	ref            service.Ref
+	target         string
+	node           *gitlab.TreeNode
+	size           int64
+	modTime        func(context.Context) (time.Time, error)
 }
 
 var _ service.ResolvedResource = &Resource{}
+var _ service.DownloadURLResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
+var _ service.TreeModeResolvedResource = &Resource{}
 
-func NewResource(client *gitlab.Client, ref service.Ref, target string, node *gitlab.TreeNode) *Resource {
+func NewResource(client *gitlab.Client, downloadClient *http.Client, ref service.Ref, target string, node *gitlab.TreeNode, size int64, modTime func(context.Context) (time.Time, error)) *Resource {
 	return &Resource{
-		client: client,
-		ref:    ref,
-		target: target,
-		node:   node,
+		client:         client,
+		downloadClient: downloadClient,
+		ref:            ref,
+		target:         target,
+		node:           node,
+		size:           size,
+		modTime:        modTime,
 	}
 }
 
 func (r *Resource) GetName() string {
-	return r.node.Name
+	return r.node.Path
 }
 
 func (r *Resource) GetSize() int64 {
-	return 0
+	return r.size
 }
 
-func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
-	// TODO switch to stream?
-	bufRes, _, err := r.client.Repositories.Blob(gitlabutil.GetRepositoryID(r.ref), r.node.ID, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "getting blob")
-	}
+func (r *Resource) GetDownloadURL() string {
+	return fmt.Sprintf("%sprojects/%s/repository/blobs/%s/raw", r.client.BaseURL(), url.PathEscape(gitlabutil.GetRepositoryID(r.ref)), r.node.ID)
+}
+
+func (r *Resource) GetTreeMode() string {
+	return r.node.Mode
+}
 
-	var apiRes apiResponse
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	return r.modTime(ctx)
+}
 
-	err = json.Unmarshal(bufRes, &apiRes)
+func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
+	res, err := r.OpenRange(ctx, 0, 0, "")
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing blob api")
+		return nil, err
 	}
 
-	var buf []byte
+	return res, nil
+}
 
-	if apiRes.Encoding == "base64" {
-		buf, err = base64.StdEncoding.DecodeString(apiRes.Content)
-		if err != nil {
-			return nil, errors.Wrap(err, "decoding blob")
-		}
-	} else {
-		return nil, errors.Wrap(err, "unsupported content encoding")
+func (r *Resource) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	res, err := httputil.GetRange(ctx, r.downloadClient, r.GetDownloadURL(), offset, length, validator)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting blob")
 	}
 
-	return ioutil.NopCloser(bytes.NewReader(buf)), nil
-}
-
-type apiResponse struct {
-	Size     int    `json:"size"`
-	Encoding string `json:"encoding"`
-	Content  string `json:"content"`
-	SHA      string `json:"sha"`
+	return res, nil
 }
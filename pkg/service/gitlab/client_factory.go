@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/bgentry/go-netrc/netrc"
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
@@ -18,31 +19,28 @@ import (
 type roundTripTransformer func(http.RoundTripper) http.RoundTripper
 
 type ClientFactory struct {
-	log               *logrus.Logger
-	httpClientFactory func() *http.Client
+	log                       *logrus.Logger
+	httpClientFactory         func() *http.Client
+	httpDownloadClientFactory func() *http.Client
 }
 
-func NewClientFactory(log *logrus.Logger, httpClientFactory func() *http.Client) *ClientFactory {
+func NewClientFactory(log *logrus.Logger, httpClientFactory, httpDownloadClientFactory func() *http.Client) *ClientFactory {
 	return &ClientFactory{
-		log:               log,
-		httpClientFactory: httpClientFactory,
+		log:                       log,
+		httpClientFactory:         httpClientFactory,
+		httpDownloadClientFactory: httpDownloadClientFactory,
 	}
 }
 
-func (cf ClientFactory) Get(ctx context.Context, lookupRef service.LookupRef) (*gitlab.Client, error) {
-	var token string
-
-	if v := os.Getenv("GITLAB_TOKEN"); v != "" {
-		cf.log.Infof("found authentication for %s: env GITLAB_TOKEN", lookupRef.Ref.Server)
-
-		token = v
-	} else {
-		var err error
+// GetDownloadClient returns a client for downloading files outside of the API.
+func (cf ClientFactory) GetDownloadClient() *http.Client {
+	return cf.httpDownloadClientFactory()
+}
 
-		token, err = cf.loadNetrc(ctx, lookupRef)
-		if err != nil {
-			return nil, errors.Wrap(err, "loading auth from netrc")
-		}
+func (cf ClientFactory) Get(ctx context.Context, lookupRef service.LookupRef) (*gitlab.Client, error) {
+	token, err := cf.getToken(ctx, lookupRef, true)
+	if err != nil {
+		return nil, err
 	}
 
 	var clientOpts = []gitlab.ClientOptionFunc{
@@ -59,7 +57,72 @@ func (cf ClientFactory) Get(ctx context.Context, lookupRef service.LookupRef) (*
 	return res, nil
 }
 
-func (cf ClientFactory) loadNetrc(ctx context.Context, lookupRef service.LookupRef) (string, error) {
+// GetAPIDownloadClient returns a client for downloading content from the API (e.g. raw blobs). It uses the same
+// authentication as the API client, but not its timeout or response cache since the content may be large.
+func (cf ClientFactory) GetAPIDownloadClient(ctx context.Context, lookupRef service.LookupRef) (*http.Client, error) {
+	token, err := cf.getToken(ctx, lookupRef, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := cf.httpDownloadClientFactory()
+
+	if token == "" {
+		return httpClient, nil
+	}
+
+	httpClient.Transport = &httputil.HostHeaderRoundTripper{
+		Base:   httpClient.Transport,
+		Host:   lookupRef.Ref.Server,
+		Header: http.Header{"Private-Token": []string{token}},
+	}
+
+	return httpClient, nil
+}
+
+// GetLFSClient returns a client for the LFS server of repositories which uses the token as basic authentication.
+func (cf ClientFactory) GetLFSClient(ctx context.Context, lookupRef service.LookupRef) (*http.Client, error) {
+	token, err := cf.getToken(ctx, lookupRef, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := cf.httpDownloadClientFactory()
+
+	if token == "" {
+		return httpClient, nil
+	}
+
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth("oauth2", token)
+
+	httpClient.Transport = &httputil.HostHeaderRoundTripper{
+		Base:   httpClient.Transport,
+		Host:   lookupRef.Ref.Server,
+		Header: req.Header,
+	}
+
+	return httpClient, nil
+}
+
+func (cf ClientFactory) getToken(ctx context.Context, lookupRef service.LookupRef, logFound bool) (string, error) {
+	if v := os.Getenv("GITLAB_TOKEN"); v != "" {
+		if logFound {
+			cf.log.Infof("found authentication for %s: env GITLAB_TOKEN", lookupRef.Ref.Server)
+		}
+
+		return v, nil
+	}
+
+	token, err := cf.loadNetrc(ctx, lookupRef, logFound)
+	if err != nil {
+		return "", errors.Wrap(err, "loading auth from netrc")
+	}
+
+	return token, nil
+}
+
+func (cf ClientFactory) loadNetrc(ctx context.Context, lookupRef service.LookupRef, logFound bool) (string, error) {
 	netrcPath := os.Getenv("NETRC")
 	if netrcPath == "" {
 		var err error
@@ -92,7 +155,9 @@ func (cf ClientFactory) loadNetrc(ctx context.Context, lookupRef service.LookupR
 		return "", nil
 	}
 
-	cf.log.Infof("found authentication for %s: netrc %s", lookupRef.Ref.Server, netrcPath)
+	if logFound {
+		cf.log.Infof("found authentication for %s: netrc %s", lookupRef.Ref.Server, netrcPath)
+	}
 
 	return machine.Password, nil
 }
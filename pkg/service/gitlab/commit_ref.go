@@ -3,28 +3,40 @@ package gitlab
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/dpb587/gget/pkg/gitlfs"
+	"github.com/dpb587/gget/pkg/globutil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/service/gitlab/archive"
 	"github.com/dpb587/gget/pkg/service/gitlab/blob"
 	"github.com/dpb587/gget/pkg/service/gitlab/gitlabutil"
 	"github.com/pkg/errors"
+	"github.com/tidwall/limiter"
 	"github.com/xanzy/go-gitlab"
 )
 
 type CommitRef struct {
-	client   *gitlab.Client
-	ref      service.Ref
-	commit   string
-	metadata service.RefMetadata
+	client            *gitlab.Client
+	apiDownloadClient *http.Client
+	lfsClient         *gitlfs.BatchClient
+	ref               service.Ref
+	commit            string
+	metadata          service.RefMetadata
 
 	archiveFileBase string
+
+	commitTime  time.Time
+	commitTimeM sync.Mutex
 }
 
 var _ service.ResolvedRef = &ReleaseRef{}
 var _ service.ResourceResolver = &CommitRef{}
+var _ service.SubmoduleResolver = &CommitRef{}
 
 func (r *CommitRef) CanonicalRef() service.Ref {
 	return r.ref
@@ -69,10 +81,12 @@ func (r *CommitRef) resolveArchiveResource(ctx context.Context, resource service
 			res,
 			archive.NewResource(
 				r.client,
+				r.apiDownloadClient,
 				r.ref,
 				r.commit,
 				candidate,
 				strings.TrimPrefix(candidate, fmt.Sprintf("%s.", r.archiveFileBase)),
+				r.getCommitTime,
 			),
 		)
 	}
@@ -81,7 +95,7 @@ func (r *CommitRef) resolveArchiveResource(ctx context.Context, resource service
 }
 
 func (r *CommitRef) resolveBlobResource(ctx context.Context, resource service.ResourceName) ([]service.ResolvedResource, error) {
-	var res []service.ResolvedResource
+	var candidates []*gitlab.TreeNode
 
 	// get the full tree
 	pt := true
@@ -102,11 +116,11 @@ func (r *CommitRef) resolveBlobResource(ctx context.Context, resource service.Re
 		for _, candidate := range nodes {
 			if candidate.Type != "blob" {
 				continue
-			} else if match, _ := filepath.Match(string(resource), candidate.Path); !match {
+			} else if match, _ := globutil.Match(string(resource), candidate.Path); !match {
 				continue
 			}
 
-			res = append(res, blob.NewResource(r.client, r.ref, r.commit, candidate))
+			candidates = append(candidates, candidate)
 		}
 
 		if resp.NextPage == 0 {
@@ -116,5 +130,121 @@ func (r *CommitRef) resolveBlobResource(ctx context.Context, resource service.Re
 		opts.ListOptions.Page = resp.NextPage
 	}
 
+	// sizes are not included in the tree
+	sizes, err := r.getBlobSizes(ctx, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting blob sizes")
+	}
+
+	var res []service.ResolvedResource
+
+	for candidateIdx, candidate := range candidates {
+		res = append(res, blob.NewResource(r.client, r.apiDownloadClient, r.ref, r.commit, candidate, sizes[candidateIdx], r.getCommitTime))
+	}
+
+	res, err = gitlfs.Resolve(ctx, r.lfsClient, res)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolving lfs objects")
+	}
+
 	return res, nil
 }
+
+// getBlobSizes requests the size of each blob from the metadata of repository files.
+func (r *CommitRef) getBlobSizes(ctx context.Context, nodes []*gitlab.TreeNode) ([]int64, error) {
+	res := make([]int64, len(nodes))
+	errs := make([]error, len(nodes))
+
+	var wg sync.WaitGroup
+
+	l := limiter.New(4)
+
+	for nodeIdx, node := range nodes {
+		wg.Add(1)
+
+		go func(nodeIdx int, node *gitlab.TreeNode) {
+			defer wg.Done()
+
+			l.Begin()
+			defer l.End()
+
+			file, _, err := r.client.RepositoryFiles.GetFileMetaData(
+				gitlabutil.GetRepositoryID(r.ref),
+				node.Path,
+				&gitlab.GetFileMetaDataOptions{Ref: &r.commit},
+				gitlab.WithContext(ctx),
+			)
+			if err != nil {
+				errs[nodeIdx] = errors.Wrapf(err, "getting metadata of %s", node.Path)
+			} else {
+				res[nodeIdx] = int64(file.Size)
+			}
+		}(nodeIdx, node)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func (r *CommitRef) ResolveSubmodules(ctx context.Context) ([]service.Submodule, error) {
+	var res []service.Submodule
+
+	pt := true
+	opts := &gitlab.ListTreeOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+		Ref:       &r.commit,
+		Recursive: &pt,
+	}
+
+	for {
+		nodes, resp, err := r.client.Repositories.ListTree(gitlabutil.GetRepositoryID(r.ref), opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting commit tree")
+		}
+
+		for _, candidate := range nodes {
+			if candidate.Type != "commit" {
+				continue
+			}
+
+			res = append(res, service.Submodule{
+				Path:   candidate.Path,
+				Commit: candidate.ID,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.ListOptions.Page = resp.NextPage
+	}
+
+	return res, nil
+}
+
+// getCommitTime is only requested when needed and then reused by all resources of the commit.
+func (r *CommitRef) getCommitTime(_ context.Context) (time.Time, error) {
+	r.commitTimeM.Lock()
+	defer r.commitTimeM.Unlock()
+
+	if r.commitTime.IsZero() {
+		commit, _, err := r.client.Commits.GetCommit(gitlabutil.GetRepositoryID(r.ref), r.commit)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "getting commit")
+		} else if commit.CommittedDate != nil {
+			r.commitTime = *commit.CommittedDate
+		}
+	}
+
+	return r.commitTime, nil
+}
@@ -2,8 +2,10 @@ package gitlab
 
 import (
 	"context"
+	"net/http"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/dpb587/gget/pkg/checksum"
 	"github.com/dpb587/gget/pkg/service"
@@ -12,16 +14,18 @@ import (
 )
 
 type ReleaseRef struct {
-	client    *gitlab.Client
-	ref       service.Ref
-	release   *gitlab.Release
-	targetRef service.ResolvedRef
+	client         *gitlab.Client
+	downloadClient *http.Client
+	ref            service.Ref
+	release        *gitlab.Release
+	targetRef      service.ResolvedRef
 
 	checksumManager checksum.Manager
 }
 
 var _ service.ResolvedRef = &ReleaseRef{}
 var _ service.ResourceResolver = &ReleaseRef{}
+var _ service.SubmoduleResolver = &ReleaseRef{}
 
 func (r *ReleaseRef) CanonicalRef() service.Ref {
 	return r.ref
@@ -39,9 +43,26 @@ func (r *ReleaseRef) ResolveResource(ctx context.Context, resourceType service.R
 	return r.targetRef.ResolveResource(ctx, resourceType, resource)
 }
 
+func (r *ReleaseRef) ResolveSubmodules(ctx context.Context) ([]service.Submodule, error) {
+	sr, ok := r.targetRef.(service.SubmoduleResolver)
+	if !ok {
+		return nil, nil
+	}
+
+	return sr.ResolveSubmodules(ctx)
+}
+
 func (r *ReleaseRef) resolveAssetResource(ctx context.Context, resource service.ResourceName) ([]service.ResolvedResource, error) {
 	var res []service.ResolvedResource
 
+	var releasedAt time.Time
+
+	if r.release.ReleasedAt != nil {
+		releasedAt = *r.release.ReleasedAt
+	} else if r.release.CreatedAt != nil {
+		releasedAt = *r.release.CreatedAt
+	}
+
 	for _, candidate := range r.release.Assets.Links {
 		// TODO kind of weird to extract from remote url "file" name, but
 		//   Name field is more traditionally a label. So currently using
@@ -53,7 +74,7 @@ func (r *ReleaseRef) resolveAssetResource(ctx context.Context, resource service.
 
 		res = append(
 			res,
-			asset.NewResource(r.client, r.ref.Owner, r.ref.Repository, candidate),
+			asset.NewResource(r.client, r.downloadClient, r.ref.Owner, r.ref.Repository, candidate, releasedAt),
 		)
 	}
 
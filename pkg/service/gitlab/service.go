@@ -7,6 +7,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/dpb587/gget/pkg/gitlfs"
 	"github.com/dpb587/gget/pkg/gitutil"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/service/gitlab/gitlabutil"
@@ -63,6 +64,18 @@ func (s Service) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (s
 		return nil, errors.Wrap(err, "building client")
 	}
 
+	apiDownloadClient, err := s.clientFactory.GetAPIDownloadClient(ctx, lookupRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "building download client")
+	}
+
+	lfsHTTPClient, err := s.clientFactory.GetLFSClient(ctx, lookupRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "building lfs client")
+	}
+
+	lfsClient := gitlfs.NewBatchClient(lfsHTTPClient, fmt.Sprintf("https://%s/%s.git/info/lfs", lookupRef.Ref.Server, gitlabutil.GetRepositoryID(lookupRef.Ref)))
+
 	canonicalRef := lookupRef.Ref
 	canonicalRef.Service = s.ServiceName()
 
@@ -82,36 +95,36 @@ func (s Service) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (s
 
 	{ // tag
 		tag, resp, err := client.Tags.GetTag(idPath, canonicalRef.Ref)
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "attempting tag resolution")
 		} else if tag != nil {
-			return s.resolveTagReference(ctx, client, canonicalRef, tag, cachedRelease)
+			return s.resolveTagReference(ctx, client, apiDownloadClient, lfsClient, canonicalRef, tag, cachedRelease)
 		}
 	}
 
 	{ // head
 		branch, resp, err := client.Branches.GetBranch(idPath, canonicalRef.Ref)
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "attempting branch resolution")
 		} else if branch != nil {
-			return s.resolveHeadReference(ctx, client, canonicalRef, branch)
+			return s.resolveHeadReference(ctx, client, apiDownloadClient, lfsClient, canonicalRef, branch)
 		}
 	}
 
 	if gitutil.PotentialCommitRE.MatchString(canonicalRef.Ref) { // commit
 		commit, resp, err := client.Commits.GetCommit(idPath, canonicalRef.Ref)
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "attempting commit resolution")
 		} else {
 			canonicalRef.Ref = commit.ID
 
-			return s.resolveCommitReference(ctx, client, canonicalRef, commit.ID)
+			return s.resolveCommitReference(ctx, client, apiDownloadClient, lfsClient, canonicalRef, commit.ID)
 		}
 	}
 
@@ -165,12 +178,14 @@ func (s Service) resolveLatest(ctx context.Context, client *gitlab.Client, looku
 	return nil, errors.New("no latest release found")
 }
 
-func (s Service) resolveCommitReference(ctx context.Context, client *gitlab.Client, ref service.Ref, commitSHA string) (service.ResolvedRef, error) {
+func (s Service) resolveCommitReference(ctx context.Context, client *gitlab.Client, apiDownloadClient *http.Client, lfsClient *gitlfs.BatchClient, ref service.Ref, commitSHA string) (service.ResolvedRef, error) {
 	res := &CommitRef{
-		client:          client,
-		ref:             ref,
-		commit:          commitSHA,
-		archiveFileBase: fmt.Sprintf("%s-%s", ref.Repository, commitSHA[0:9]),
+		client:            client,
+		apiDownloadClient: apiDownloadClient,
+		lfsClient:         lfsClient,
+		ref:               ref,
+		commit:            commitSHA,
+		archiveFileBase:   fmt.Sprintf("%s-%s", ref.Repository, commitSHA[0:9]),
 		metadata: service.RefMetadata{
 			{
 				Name:  "commit",
@@ -182,15 +197,17 @@ func (s Service) resolveCommitReference(ctx context.Context, client *gitlab.Clie
 	return res, nil
 }
 
-func (s Service) resolveHeadReference(ctx context.Context, client *gitlab.Client, ref service.Ref, headRef *gitlab.Branch) (service.ResolvedRef, error) {
+func (s Service) resolveHeadReference(ctx context.Context, client *gitlab.Client, apiDownloadClient *http.Client, lfsClient *gitlfs.BatchClient, ref service.Ref, headRef *gitlab.Branch) (service.ResolvedRef, error) {
 	branchName := headRef.Name
 	commitSHA := headRef.Commit.ID
 
 	res := &CommitRef{
-		client:          client,
-		ref:             ref,
-		commit:          commitSHA,
-		archiveFileBase: fmt.Sprintf("%s-%s", ref.Repository, path.Base(branchName)),
+		client:            client,
+		apiDownloadClient: apiDownloadClient,
+		lfsClient:         lfsClient,
+		ref:               ref,
+		commit:            commitSHA,
+		archiveFileBase:   fmt.Sprintf("%s-%s", ref.Repository, path.Base(branchName)),
 		metadata: service.RefMetadata{
 			{
 				Name:  "branch",
@@ -206,15 +223,17 @@ func (s Service) resolveHeadReference(ctx context.Context, client *gitlab.Client
 	return res, nil
 }
 
-func (s Service) resolveTagReference(ctx context.Context, client *gitlab.Client, ref service.Ref, tagRef *gitlab.Tag, cachedRelease *gitlab.Release) (service.ResolvedRef, error) {
+func (s Service) resolveTagReference(ctx context.Context, client *gitlab.Client, apiDownloadClient *http.Client, lfsClient *gitlfs.BatchClient, ref service.Ref, tagRef *gitlab.Tag, cachedRelease *gitlab.Release) (service.ResolvedRef, error) {
 	tagName := tagRef.Name
 	commitSHA := tagRef.Commit.ID
 
 	var res service.ResolvedRef = &CommitRef{
-		client:          client,
-		ref:             ref,
-		commit:          commitSHA,
-		archiveFileBase: fmt.Sprintf("%s-%s", ref.Repository, tagName),
+		client:            client,
+		apiDownloadClient: apiDownloadClient,
+		lfsClient:         lfsClient,
+		ref:               ref,
+		commit:            commitSHA,
+		archiveFileBase:   fmt.Sprintf("%s-%s", ref.Repository, tagName),
 		metadata: service.RefMetadata{
 			{
 				Name:  "tag",
@@ -236,7 +255,7 @@ func (s Service) resolveTagReference(ctx context.Context, client *gitlab.Client,
 		var err error
 
 		release, resp, err = client.Releases.GetRelease(gitlabutil.GetRepositoryID(ref), tagName)
-		if resp.StatusCode == http.StatusNotFound {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			// oh well
 		} else if err != nil {
 			return nil, errors.Wrap(err, "getting release by tag")
@@ -245,10 +264,11 @@ func (s Service) resolveTagReference(ctx context.Context, client *gitlab.Client,
 
 	if release != nil {
 		res = &ReleaseRef{
-			client:    client,
-			ref:       ref,
-			release:   release,
-			targetRef: res,
+			client:         client,
+			downloadClient: s.clientFactory.GetDownloadClient(),
+			ref:            ref,
+			release:        release,
+			targetRef:      res,
 			// checksumManager: NewReleaseChecksumManager(client, ref.Owner, ref.Repository, release), // TODO
 		}
 	}
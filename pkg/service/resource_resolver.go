@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/dpb587/gget/pkg/checksum"
 )
@@ -20,3 +21,37 @@ type ResolvedResource interface {
 type ChecksumSupportedResolvedResource interface {
 	GetChecksums(ctx context.Context, algos checksum.AlgorithmList) (checksum.ChecksumList, error)
 }
+
+// DownloadURLResolvedResource describes where the content of a resource is downloaded from.
+type DownloadURLResolvedResource interface {
+	GetDownloadURL() string
+}
+
+// ArchiveURLResolvedResource describes the public URL of an archive which is otherwise downloaded through an API. It is
+// the URL which mirrors are expected to serve.
+type ArchiveURLResolvedResource interface {
+	GetArchiveURL() string
+}
+
+// ModTimeResolvedResource describes when the content of a resource was last changed (e.g. when it was uploaded or
+// committed).
+type ModTimeResolvedResource interface {
+	GetModTime(ctx context.Context) (time.Time, error)
+}
+
+// TreeModeResolvedResource describes the mode of a blob within its repository tree (e.g. 100644, 100755, or 120000 for
+// symlinks).
+type TreeModeResolvedResource interface {
+	GetTreeMode() string
+}
+
+// Submodule is a commit of another repository which is referenced from a repository tree.
+type Submodule struct {
+	Path   string
+	Commit string
+}
+
+// SubmoduleResolver finds the submodules of a tree. The repository of a submodule is configured by the .gitmodules blob.
+type SubmoduleResolver interface {
+	ResolveSubmodules(ctx context.Context) ([]Submodule, error)
+}
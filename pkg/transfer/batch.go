@@ -19,8 +19,9 @@ type Batch struct {
 	limiter   *limiter.Limiter
 	output    io.Writer
 
-	errs  []string
-	errsM sync.Mutex
+	errs      []string
+	succeeded map[*Transfer]struct{}
+	errsM     sync.Mutex
 }
 
 func NewBatch(log logrus.FieldLogger, transfers []*Transfer, parallel int, output io.Writer) *Batch {
@@ -29,10 +30,99 @@ func NewBatch(log logrus.FieldLogger, transfers []*Transfer, parallel int, outpu
 		transfers: transfers,
 		limiter:   limiter.New(parallel),
 		output:    output,
+		succeeded: map[*Transfer]struct{}{},
+	}
+}
+
+// LimitRate throttles the combined throughput of all downloads of the batch.
+func (b *Batch) LimitRate(bytesPerSecond int64) {
+	limiter := newRateLimiter(bytesPerSecond)
+
+	for _, xfer := range b.transfers {
+		xfer.rateLimiter = limiter
 	}
 }
 
 func (b *Batch) Transfer(ctx context.Context, failFast bool) error {
+	return b.run(ctx, failFast)
+}
+
+// TransferAtomic defers the commit steps of every transfer until all of them succeeded and then commits them together.
+// Once a transfer fails, the others are aborted and the temporary files of every transfer are removed. If a commit
+// fails, the files replaced by earlier commits are restored (although commands which already ran are not undone).
+func (b *Batch) TransferAtomic(ctx context.Context) error {
+	// a later commit would replace the earlier one, and rolling back could not restore the original
+	committers := map[string]*Transfer{}
+
+	for _, xfer := range b.transfers {
+		target := xfer.commitTarget()
+		if target == "" {
+			continue
+		} else if committer, found := committers[target]; found {
+			return fmt.Errorf("conflicting transfers: %s and %s are both committed to %s", committer.GetSubject(), xfer.GetSubject(), target)
+		}
+
+		committers[target] = xfer
+	}
+
+	for _, xfer := range b.transfers {
+		xfer.deferred = &deferredCommit{}
+	}
+
+	err := b.run(ctx, true)
+	if err == nil {
+		// not expected to take long, so an interruption is ignored rather than leaving a partial commit
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		b.discard(b.transfers)
+
+		return err
+	}
+
+	journal := &Journal{}
+
+	for _, xfer := range b.transfers {
+		err := xfer.commit(ctx, journal)
+		if err != nil {
+			b.discard(b.transfers)
+
+			rollbackErr := journal.Rollback()
+			if rollbackErr != nil {
+				b.log.Warn(rollbackErr)
+			}
+
+			return errors.Wrapf(err, "committing %s", xfer.GetSubject())
+		}
+	}
+
+	err = journal.Finish()
+	if err != nil {
+		b.log.Warn(err)
+	}
+
+	return nil
+}
+
+// discard cleans up transfers which will not be committed; they are no longer considered successful.
+func (b *Batch) discard(transfers []*Transfer) {
+	b.errsM.Lock()
+	defer b.errsM.Unlock()
+
+	for _, xfer := range transfers {
+		delete(b.succeeded, xfer)
+
+		err := xfer.discard()
+		if err != nil {
+			b.log.Warn(errors.Wrapf(err, "discarding %s", xfer.GetSubject()))
+		}
+	}
+}
+
+func (b *Batch) run(ctx context.Context, failFast bool) error {
+	parent := ctx
+
 	pb := mpb.New(mpb.WithWidth(1), mpb.WithOutput(b.output))
 
 	for _, d := range b.transfers {
@@ -42,22 +132,37 @@ func (b *Batch) Transfer(ctx context.Context, failFast bool) error {
 	var cancel context.CancelFunc
 	if failFast {
 		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
 	}
 
+	var wg sync.WaitGroup
+
 	for idx := range b.transfers {
-		go b.transfer(idx, ctx, cancel)
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			b.transfer(idx, parent, ctx, cancel)
+		}(idx)
 	}
 
 	pb.Wait()
+	wg.Wait()
 
-	if len(b.errs) > 0 {
+	if err := parent.Err(); err != nil {
+		// partial files are not expected to be resumed after an interruption
+		b.discard(b.Failed())
+
+		return err
+	} else if len(b.errs) > 0 {
 		return fmt.Errorf("transfers failed: %s", strings.Join(b.errs, ", "))
 	}
 
 	return nil
 }
 
-func (b *Batch) transfer(idx int, ctx context.Context, cancel context.CancelFunc) {
+func (b *Batch) transfer(idx int, parent, ctx context.Context, cancel context.CancelFunc) {
 	b.limiter.Begin()
 	defer b.limiter.End()
 
@@ -67,18 +172,29 @@ func (b *Batch) transfer(idx int, ctx context.Context, cancel context.CancelFunc
 	errsLen := len(b.errs)
 	b.errsM.Unlock()
 
-	if errsLen > 0 && cancel != nil {
+	if parent.Err() != nil {
+		xfer.finalize("!", cancelledStatus(parent))
+
+		return
+	} else if errsLen > 0 && cancel != nil {
 		xfer.finalize("!", "skipped (due to previous error)")
 
 		return
 	}
 
-	if err := xfer.Execute(ctx); err != nil {
+	err := xfer.Execute(ctx)
+	if err == nil {
+		b.errsM.Lock()
+		b.succeeded[xfer] = struct{}{}
+		b.errsM.Unlock()
+	} else {
 		// only warning since it should be handled/printed elsewhere
 		b.log.Warn(errors.Wrapf(err, "downloading %s", xfer.GetSubject()))
 
 		b.errsM.Lock()
-		if cancel != nil && len(b.errs) > 0 {
+		if parent.Err() != nil {
+			xfer.finalize("!", cancelledStatus(parent))
+		} else if cancel != nil && len(b.errs) > 0 {
 			// assume context was canceled and ignore as root cause
 			xfer.finalize("!", "aborted (due to previous error)")
 		} else {
@@ -92,3 +208,27 @@ func (b *Batch) transfer(idx int, ctx context.Context, cancel context.CancelFunc
 		}
 	}
 }
+
+func cancelledStatus(ctx context.Context) string {
+	if ctx.Err() == context.DeadlineExceeded {
+		return "timed out"
+	}
+
+	return "cancelled"
+}
+
+// Failed returns the transfers which did not succeed, including those skipped or aborted due to a previous error.
+func (b *Batch) Failed() []*Transfer {
+	b.errsM.Lock()
+	defer b.errsM.Unlock()
+
+	var res []*Transfer
+
+	for _, xfer := range b.transfers {
+		if _, found := b.succeeded[xfer]; !found {
+			res = append(res, xfer)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,198 @@
+package transfer_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/retry"
+	. "github.com/dpb587/gget/pkg/transfer"
+	"github.com/dpb587/gget/pkg/transfer/step"
+)
+
+var _ = Describe("Batch", func() {
+	var server *httptest.Server
+	var content []byte
+	var tmpdir string
+
+	BeforeEach(func() {
+		content = bytes.Repeat([]byte("0123456789"), 1024)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+		}))
+
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-batch-test-")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	newTransfer := func(name string, expected []byte) *Transfer {
+		target := filepath.Join(tmpdir, name)
+
+		return NewTransfer(
+			testOrigin{url: server.URL, size: int64(len(content))},
+			[]Step{
+				&step.TempFileTarget{Target: target},
+				&step.VerifyChecksum{Verifier: checksum.NewHashVerifier(checksum.SHA256, expected, sha256.New())},
+				&step.Rename{Target: target},
+			},
+			Options{RetryPolicy: retry.NewPolicy(0), Segments: 1},
+		)
+	}
+
+	newBatch := func(transfers ...*Transfer) *Batch {
+		log := logrus.New()
+		log.Out = ioutil.Discard
+
+		return NewBatch(log, transfers, 2, ioutil.Discard)
+	}
+
+	It("removes partial files when cancelled", func() {
+		started := make(chan struct{})
+
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-length", strconv.Itoa(len(content)))
+			w.Write(content[0:1024])
+			w.(http.Flusher).Flush()
+
+			close(started)
+			<-r.Context().Done()
+		})
+
+		sum := sha256.Sum256(content)
+		batch := newBatch(newTransfer("result", sum[:]))
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			<-started
+			cancel()
+		}()
+
+		Expect(batch.Transfer(ctx, false)).To(Equal(context.Canceled))
+		Expect(batch.Failed()).To(HaveLen(1))
+
+		entries, err := ioutil.ReadDir(tmpdir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("limits the combined rate", func() {
+		sum := sha256.Sum256(content)
+		batch := newBatch(newTransfer("first", sum[:]), newTransfer("second", sum[:]))
+
+		// twice the content, but the first second is allowed as a burst
+		batch.LimitRate(int64(len(content)))
+
+		started := time.Now()
+
+		Expect(batch.Transfer(context.Background(), false)).To(Succeed())
+		Expect(time.Since(started)).To(BeNumerically(">=", 800*time.Millisecond))
+	})
+
+	Context("atomic", func() {
+		It("commits every transfer", func() {
+			sum := sha256.Sum256(content)
+
+			Expect(newBatch(newTransfer("first", sum[:]), newTransfer("second", sum[:])).TransferAtomic(context.Background())).To(Succeed())
+
+			for _, name := range []string{"first", "second"} {
+				buf, err := ioutil.ReadFile(filepath.Join(tmpdir, name))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(buf).To(Equal(content))
+			}
+
+			matches, _ := filepath.Glob(filepath.Join(tmpdir, ".gget-*"))
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("discards every transfer when one fails", func() {
+			sum := sha256.Sum256(content)
+			invalid := sha256.Sum256([]byte("invalid"))
+
+			batch := newBatch(newTransfer("first", sum[:]), newTransfer("second", invalid[:]))
+
+			Expect(batch.TransferAtomic(context.Background())).ToNot(Succeed())
+			Expect(batch.Failed()).To(HaveLen(2))
+
+			entries, err := ioutil.ReadDir(tmpdir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("restores replaced files when a later commit fails", func() {
+			sum := sha256.Sum256(content)
+
+			Expect(ioutil.WriteFile(filepath.Join(tmpdir, "first"), []byte("original"), 0644)).To(Succeed())
+
+			// a file cannot replace a non-empty directory
+			Expect(os.MkdirAll(filepath.Join(tmpdir, "second", "nested"), 0755)).To(Succeed())
+
+			batch := newBatch(newTransfer("first", sum[:]), newTransfer("second", sum[:]))
+
+			Expect(batch.TransferAtomic(context.Background())).To(MatchError(ContainSubstring("committing")))
+			Expect(batch.Failed()).To(HaveLen(2))
+
+			buf, err := ioutil.ReadFile(filepath.Join(tmpdir, "first"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buf)).To(Equal("original"))
+
+			matches, _ := filepath.Glob(filepath.Join(tmpdir, ".gget-*"))
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("removes new files when a later commit fails", func() {
+			sum := sha256.Sum256(content)
+
+			Expect(os.MkdirAll(filepath.Join(tmpdir, "second", "nested"), 0755)).To(Succeed())
+
+			Expect(newBatch(newTransfer("first", sum[:]), newTransfer("second", sum[:])).TransferAtomic(context.Background())).ToNot(Succeed())
+
+			_, err := os.Stat(filepath.Join(tmpdir, "first"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("rejects transfers which are committed to the same target", func() {
+			sum := sha256.Sum256(content)
+
+			err := newBatch(newTransfer("first", sum[:]), newTransfer("first", sum[:])).TransferAtomic(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("conflicting transfers")))
+
+			entries, err := ioutil.ReadDir(tmpdir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("discards every transfer when cancelled", func() {
+			sum := sha256.Sum256(content)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			Expect(newBatch(newTransfer("first", sum[:])).TransferAtomic(ctx)).ToNot(Succeed())
+
+			entries, err := ioutil.ReadDir(tmpdir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,261 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/dpb587/gget/pkg/httputil"
+	"github.com/dpb587/gget/pkg/retry"
+	"github.com/pkg/errors"
+)
+
+type downloadState struct {
+	writers []io.Writer
+	target  ResumableTarget
+
+	// written is the number of bytes received by all writers.
+	written int64
+
+	// retained is the number of bytes the target has from a previous attempt, but other writers have not received.
+	retained int64
+
+	validator string
+	resumed   bool
+	linked    bool
+}
+
+func (w Transfer) newDownloadState() (*downloadState, error) {
+	ds := &downloadState{}
+
+	for _, step := range w.steps {
+		writer, ok := step.(io.Writer)
+		if !ok {
+			continue
+		}
+
+		ds.writers = append(ds.writers, writer)
+
+		if target, ok := writer.(ResumableTarget); ok && ds.target == nil {
+			ds.target = target
+		}
+	}
+
+	if len(ds.writers) == 0 {
+		return nil, fmt.Errorf("no download target found")
+	}
+
+	if ds.target != nil {
+		if _, ok := w.origin.(RangeDownloadAsset); ok {
+			retained, validator, err := ds.target.Resume()
+			if err != nil {
+				return nil, errors.Wrap(err, "resuming target")
+			}
+
+			ds.retained = retained
+			ds.validator = validator
+		}
+	}
+
+	return ds, nil
+}
+
+func (w Transfer) downloadAll(ctx context.Context, ds *downloadState) error {
+	linked, err := w.link(ds)
+	if err != nil {
+		return err
+	} else if linked {
+		return nil
+	}
+
+	segmented, err := w.downloadSegments(ctx, ds)
+	if err != nil {
+		return err
+	} else if segmented {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := w.download(ctx, ds)
+		if err == nil {
+			return nil
+		} else if !w.canRetry(ctx, err, attempt) {
+			return err
+		}
+
+		retry.Notify(ctx, attempt+1, err)
+
+		if err := w.opts.RetryPolicy.Wait(ctx, attempt, 0); err != nil {
+			return errors.Wrap(err, "waiting to retry")
+		}
+	}
+}
+
+// link uses a local origin as the retained content of the target rather than downloading it.
+func (w Transfer) link(ds *downloadState) (bool, error) {
+	lo, ok := w.origin.(LocalDownloadAsset)
+	if !ok {
+		return false, nil
+	}
+
+	target, ok := ds.target.(LinkTarget)
+	if !ok {
+		return false, nil
+	}
+
+	err := target.Link(lo.GetLocalPath())
+	if err != nil {
+		return false, errors.Wrap(err, "linking")
+	}
+
+	w.bars[1].SetTotal(1, true)
+
+	ds.retained = w.origin.GetSize()
+	ds.linked = true
+
+	err = w.replay(ds, true)
+	if err != nil {
+		return false, errors.Wrap(err, "replaying linked file")
+	}
+
+	return true, nil
+}
+
+func (w Transfer) canRetry(ctx context.Context, err error, attempt int) bool {
+	if _, transient := err.(sourceReadError); !transient {
+		return false
+	} else if ctx.Err() != nil {
+		return false
+	}
+
+	return w.opts.RetryPolicy.AllowsAttempt(attempt + 1)
+}
+
+// download connects to the origin and copies to the writers, continuing after any previously-received bytes.
+func (w Transfer) download(ctx context.Context, ds *downloadState) error {
+	offset := ds.written + ds.retained
+
+	assetHandle, err := w.open(ctx, offset, ds.validator)
+	if err != nil {
+		return errors.Wrap(err, "connecting")
+	}
+
+	defer assetHandle.Close()
+
+	w.bars[1].SetTotal(1, true)
+
+	if assetHandle.Offset == offset {
+		if offset == 0 && ds.target != nil {
+			err = ds.target.Reset(assetHandle.Validator)
+			if err != nil {
+				return errors.Wrap(err, "resetting target")
+			}
+		} else if ds.retained > 0 {
+			err = w.replay(ds, true)
+			if err != nil {
+				return errors.Wrap(err, "replaying retained download")
+			}
+
+			ds.resumed = true
+		}
+	} else if assetHandle.Offset == 0 {
+		// origin is sending everything again
+		if ds.written > 0 && ds.validator != "" && assetHandle.Validator != "" && ds.validator != assetHandle.Validator {
+			return fmt.Errorf("remote content changed during download")
+		}
+
+		if ds.retained > 0 {
+			ds.retained = 0
+
+			err = ds.target.Reset(assetHandle.Validator)
+			if err != nil {
+				return errors.Wrap(err, "resetting target")
+			}
+		}
+
+		if ds.written > 0 {
+			_, err = io.CopyN(ioutil.Discard, assetHandle, ds.written)
+			if err != nil {
+				return sourceReadError{errors.Wrap(err, "skipping previously downloaded bytes")}
+			}
+		}
+	} else {
+		return fmt.Errorf("unexpected download offset: expected %d, but received %d", offset, assetHandle.Offset)
+	}
+
+	if assetHandle.Validator != "" {
+		ds.validator = assetHandle.Validator
+	}
+
+	r := w.bars[2].ProxyReader(newRateLimitedReader(ctx, assetHandle, w.rateLimiter))
+	defer r.Close()
+
+	sr := newSourceReader(r, assetHandle, w.opts.StallTimeout)
+	defer sr.stop()
+
+	n, err := io.Copy(io.MultiWriter(ds.writers...), sr)
+	ds.written += n
+
+	if err != nil {
+		if sr.err != nil {
+			return sourceReadError{errors.Wrap(sr.err, "downloading")}
+		}
+
+		return errors.Wrap(err, "downloading")
+	}
+
+	return nil
+}
+
+func (w Transfer) open(ctx context.Context, offset int64, validator string) (*httputil.RangeReader, error) {
+	if ro, ok := w.origin.(RangeDownloadAsset); ok {
+		return ro.OpenRange(ctx, offset, 0, validator)
+	}
+
+	assetHandle, err := w.origin.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httputil.RangeReader{ReadCloser: assetHandle}, nil
+}
+
+// replay sends content retained by the target to the other writers (e.g. checksum verifiers).
+func (w Transfer) replay(ds *downloadState, progress bool) error {
+	var others []io.Writer
+
+	for _, writer := range ds.writers {
+		if writer == ds.target {
+			continue
+		}
+
+		others = append(others, writer)
+	}
+
+	fh, err := ds.target.OpenRetained()
+	if err != nil {
+		return errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	var r io.Reader = fh
+
+	if progress {
+		pr := w.bars[2].ProxyReader(fh)
+		defer pr.Close()
+
+		r = pr
+	}
+
+	_, err = io.CopyN(io.MultiWriter(append(others, ioutil.Discard)...), r, ds.retained)
+	if err != nil {
+		return errors.Wrap(err, "reading")
+	}
+
+	ds.written += ds.retained
+	ds.retained = 0
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package transfer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Journal records the files replaced by commit steps so that they can be restored if a later commit fails. Replaced
+// files are kept as backups until the journal is finished.
+type Journal struct {
+	entries []journalEntry
+	targets map[string]struct{}
+}
+
+type journalEntry struct {
+	target string
+
+	// backup is empty if the target did not previously exist.
+	backup string
+}
+
+// Rename moves src to target, similar to os.Rename, after moving any existing file at target to a backup.
+func (j *Journal) Rename(src, target string) error {
+	if _, found := j.targets[target]; found {
+		// the original was already backed up
+		return os.Rename(src, target)
+	}
+
+	var backup string
+
+	fi, err := os.Lstat(target)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "checking target")
+	} else if err == nil && !fi.IsDir() {
+		dir, base := filepath.Split(target)
+		backup = filepath.Join(dir, fmt.Sprintf(".gget-%s.backup", base))
+
+		err = os.Rename(target, backup)
+		if err != nil {
+			return errors.Wrap(err, "backing up target")
+		}
+	}
+
+	err = os.Rename(src, target)
+	if err != nil {
+		if backup != "" {
+			os.Rename(backup, target)
+		}
+
+		return err
+	}
+
+	if j.targets == nil {
+		j.targets = map[string]struct{}{}
+	}
+
+	j.targets[target] = struct{}{}
+	j.entries = append(j.entries, journalEntry{
+		target: target,
+		backup: backup,
+	})
+
+	return nil
+}
+
+// Rollback removes the renamed files and restores their backups, in reverse order.
+func (j *Journal) Rollback() error {
+	var errs []string
+
+	for entryIdx := len(j.entries) - 1; entryIdx >= 0; entryIdx-- {
+		entry := j.entries[entryIdx]
+
+		err := os.Remove(entry.target)
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, errors.Wrapf(err, "removing %s", entry.target).Error())
+
+			continue
+		}
+
+		if entry.backup == "" {
+			continue
+		}
+
+		err = os.Rename(entry.backup, entry.target)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "restoring %s", entry.target).Error())
+		}
+	}
+
+	j.entries = nil
+	j.targets = nil
+
+	if len(errs) > 0 {
+		return fmt.Errorf("rolling back: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// Finish removes the backups once every commit succeeded.
+func (j *Journal) Finish() error {
+	var errs []string
+
+	for _, entry := range j.entries {
+		if entry.backup == "" {
+			continue
+		}
+
+		err := os.Remove(entry.backup)
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, errors.Wrapf(err, "removing %s", entry.backup).Error())
+		}
+	}
+
+	j.entries = nil
+	j.targets = nil
+
+	if len(errs) > 0 {
+		return fmt.Errorf("removing backups: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package transfer
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitedRead keeps throttled reads small so progress remains smooth.
+const maxRateLimitedRead = 32 * 1024
+
+// newRateLimiter creates a token bucket of bytes which may be shared by concurrent downloads.
+func newRateLimiter(bytesPerSecond int64) *rate.Limiter {
+	burst := bytesPerSecond
+	if burst > maxRateLimitedRead {
+		burst = maxRateLimitedRead
+	} else if burst < 1 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst))
+}
+
+// rateLimitedReader waits for the limiter to allow the bytes which were read before returning them.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func newRateLimitedReader(ctx context.Context, r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+
+	return &rateLimitedReader{
+		ctx:     ctx,
+		r:       r,
+		limiter: limiter,
+	}
+}
+
+func (rlr *rateLimitedReader) Read(p []byte) (int, error) {
+	if burst := rlr.limiter.Burst(); len(p) > burst {
+		p = p[0:burst]
+	}
+
+	n, err := rlr.r.Read(p)
+	if n > 0 {
+		if werr := rlr.limiter.WaitN(rlr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
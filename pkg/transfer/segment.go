@@ -0,0 +1,181 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/dpb587/gget/pkg/httputil"
+	"github.com/dpb587/gget/pkg/retry"
+	"github.com/pkg/errors"
+)
+
+// minSegmentSize avoids splitting downloads where the overhead of additional connections is not worthwhile.
+const minSegmentSize = 1024 * 1024
+
+// downloadSegments concurrently downloads ranges of the origin into the target, returning false if the origin or
+// target do not support it.
+func (w Transfer) downloadSegments(ctx context.Context, ds *downloadState) (bool, error) {
+	if w.opts.Segments < 2 || ds.retained > 0 {
+		return false, nil
+	}
+
+	origin, ok := w.origin.(RangeDownloadAsset)
+	if !ok {
+		return false, nil
+	}
+
+	target, ok := ds.target.(SegmentTarget)
+	if !ok {
+		return false, nil
+	}
+
+	size := w.origin.GetSize()
+
+	segments := int64(w.opts.Segments)
+	if maxSegments := size / minSegmentSize; maxSegments < segments {
+		segments = maxSegments
+	}
+
+	if segments < 2 {
+		return false, nil
+	}
+
+	segmentSize := (size + segments - 1) / segments
+
+	// the first segment confirms ranges are supported
+	firstHandle, err := origin.OpenRange(ctx, 0, segmentSize, "")
+	if err != nil {
+		return false, errors.Wrap(err, "connecting")
+	}
+
+	if firstHandle.Validator == "" || firstHandle.Size != size {
+		firstHandle.Close()
+
+		return false, nil
+	}
+
+	w.bars[1].SetTotal(1, true)
+
+	// partial segments leave gaps which cannot be resumed later
+	err = target.Reset("")
+	if err != nil {
+		firstHandle.Close()
+
+		return false, errors.Wrap(err, "resetting target")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errs []error
+	var errsM sync.Mutex
+
+	for segment := int64(0); segment < segments; segment++ {
+		start := segment * segmentSize
+		length := segmentSize
+
+		if start+length > size {
+			length = size - start
+		}
+
+		var handle *httputil.RangeReader
+
+		if segment == 0 {
+			handle = firstHandle
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := w.downloadSegment(ctx, origin, target, handle, start, length, firstHandle.Validator)
+			if err != nil {
+				errsM.Lock()
+				errs = append(errs, errors.Wrapf(err, "downloading segment %d-%d", start, start+length-1))
+				errsM.Unlock()
+
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return true, errs[0]
+	}
+
+	// other writers expect content in order
+	ds.retained = size
+
+	err = w.replay(ds, false)
+	if err != nil {
+		return true, errors.Wrap(err, "verifying segments")
+	}
+
+	return true, nil
+}
+
+func (w Transfer) downloadSegment(ctx context.Context, origin RangeDownloadAsset, target io.WriterAt, handle *httputil.RangeReader, start, length int64, validator string) error {
+	var written int64
+
+	for attempt := 1; ; attempt++ {
+		if handle == nil {
+			var err error
+
+			handle, err = origin.OpenRange(ctx, start+written, length-written, validator)
+			if err != nil {
+				return errors.Wrap(err, "connecting")
+			} else if handle.Offset != start+written || handle.Validator != validator {
+				handle.Close()
+
+				return fmt.Errorf("remote content changed during download")
+			}
+		}
+
+		r := w.bars[2].ProxyReader(newRateLimitedReader(ctx, handle, w.rateLimiter))
+		sr := newSourceReader(r, handle, w.opts.StallTimeout)
+
+		n, err := io.CopyN(&offsetWriter{w: target, offset: start + written}, sr, length-written)
+		written += n
+
+		sr.stop()
+		r.Close()
+		handle = nil
+
+		if err == nil {
+			return nil
+		} else if err == io.EOF {
+			// connection ended early
+			err = sourceReadError{errors.Wrap(io.ErrUnexpectedEOF, "downloading")}
+		} else if sr.err != nil {
+			err = sourceReadError{errors.Wrap(sr.err, "downloading")}
+		}
+
+		if !w.canRetry(ctx, err, attempt) {
+			return err
+		}
+
+		retry.Notify(ctx, attempt+1, err)
+
+		if err := w.opts.RetryPolicy.Wait(ctx, attempt, 0); err != nil {
+			return errors.Wrap(err, "waiting to retry")
+		}
+	}
+}
+
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,69 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// sourceReader tracks read errors to differentiate them from errors of the writers. If a stall timeout is used, the
+// source is closed once no content has been received for the duration so that a blocked read fails.
+type sourceReader struct {
+	r   io.Reader
+	err error
+
+	stallTimeout time.Duration
+	stallTimer   *time.Timer
+	stalled      int32
+}
+
+func newSourceReader(r io.Reader, closer io.Closer, stallTimeout time.Duration) *sourceReader {
+	sr := &sourceReader{
+		r:            r,
+		stallTimeout: stallTimeout,
+	}
+
+	if stallTimeout > 0 {
+		sr.stallTimer = time.AfterFunc(stallTimeout, func() {
+			atomic.StoreInt32(&sr.stalled, 1)
+			closer.Close()
+		})
+	}
+
+	return sr
+}
+
+func (sr *sourceReader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+
+	if sr.stallTimer != nil {
+		if err != nil {
+			sr.stallTimer.Stop()
+		} else if n > 0 {
+			sr.stallTimer.Reset(sr.stallTimeout)
+		}
+	}
+
+	if err != nil && err != io.EOF {
+		if atomic.LoadInt32(&sr.stalled) == 1 {
+			err = fmt.Errorf("stalled (no content received for %s)", sr.stallTimeout)
+		}
+
+		sr.err = err
+	}
+
+	return n, err
+}
+
+// stop stops watching for a stall once the source is no longer read.
+func (sr *sourceReader) stop() {
+	if sr.stallTimer != nil {
+		sr.stallTimer.Stop()
+	}
+}
+
+// sourceReadError is a failure of the origin which may be resolved by connecting again.
+type sourceReadError struct {
+	error
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/dpb587/gget/pkg/httputil"
 	"github.com/vbauerster/mpb/v4"
 )
 
@@ -11,6 +12,9 @@ type State struct {
 	Bar           *mpb.Bar
 	LocalFilePath string
 	Results       []string
+
+	// Journal is set while committing a batch atomically; commit steps should replace files with its Rename.
+	Journal *Journal
 }
 
 type DownloadAsset interface {
@@ -18,3 +22,13 @@ type DownloadAsset interface {
 	GetSize() int64
 	Open(ctx context.Context) (io.ReadCloser, error)
 }
+
+// RangeDownloadAsset supports downloading a range of content, such as continuing from previously-received content.
+type RangeDownloadAsset interface {
+	OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error)
+}
+
+// LocalDownloadAsset is already available on the local filesystem, such as from a cache.
+type LocalDownloadAsset interface {
+	GetLocalPath() string
+}
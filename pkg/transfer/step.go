@@ -2,6 +2,7 @@ package transfer
 
 import (
 	"context"
+	"io"
 
 	"github.com/vbauerster/mpb/v4/decor"
 )
@@ -10,3 +11,47 @@ type Step interface {
 	GetProgressParams() (int64, decor.Decorator)
 	Execute(ctx context.Context, s *State) error
 }
+
+// CommitStep makes the result of a transfer visible at its target, such as by renaming a temporary file. It (and any
+// later steps) may be deferred until every transfer of a batch succeeded.
+type CommitStep interface {
+	Step
+
+	// CommitTarget is the path which is replaced by the step. It is empty when the step only adds to an existing
+	// directory, which other steps may also do.
+	CommitTarget() string
+}
+
+// CleanupStep has temporary files which should be removed when a transfer is abandoned rather than resumed later.
+type CleanupStep interface {
+	Step
+	Cleanup() error
+}
+
+// ResumableTarget is a download target which may retain content from a previous attempt.
+type ResumableTarget interface {
+	io.Writer
+
+	// Resume returns the size and validator of retained content, and continues writing after it.
+	Resume() (int64, string, error)
+
+	// OpenRetained opens a reader of the retained content.
+	OpenRetained() (io.ReadCloser, error)
+
+	// Reset discards any retained content and remembers the validator of the new content.
+	Reset(validator string) error
+}
+
+// SegmentTarget is a download target which supports writing content out of order.
+type SegmentTarget interface {
+	ResumableTarget
+	io.WriterAt
+}
+
+// LinkTarget is a download target which may reuse an existing local file rather than downloading its content.
+type LinkTarget interface {
+	ResumableTarget
+
+	// Link replaces any retained content with a copy of the local file, which is then considered retained.
+	Link(path string) error
+}
@@ -0,0 +1,56 @@
+package step
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+
+	"github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// CacheStore adds the downloaded file to the cache. It should be ordered after any checksum verification.
+type CacheStore struct {
+	Cache *cache.Cache
+	Keys  []string
+
+	hasher hash.Hash
+}
+
+var _ transfer.Step = &CacheStore{}
+var _ io.Writer = &CacheStore{}
+
+func (dpi *CacheStore) GetProgressParams() (int64, decor.Decorator) {
+	return 0, nil
+}
+
+func (dpi *CacheStore) Write(p []byte) (int, error) {
+	if dpi.hasher == nil {
+		dpi.hasher = sha256.New()
+	}
+
+	return dpi.hasher.Write(p)
+}
+
+func (dpi *CacheStore) Execute(_ context.Context, state *transfer.State) error {
+	if dpi.hasher == nil {
+		dpi.hasher = sha256.New()
+	}
+
+	digest := dpi.hasher.Sum(nil)
+
+	_, err := dpi.Cache.Store(
+		state.LocalFilePath,
+		digest,
+		append(dpi.Keys, cache.ChecksumKey(checksum.SHA256, digest))...,
+	)
+	if err != nil {
+		return errors.Wrap(err, "caching")
+	}
+
+	return nil
+}
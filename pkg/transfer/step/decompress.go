@@ -0,0 +1,172 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dpb587/gget/pkg/archive"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// Decompress decodes the download as it is received, leaving the original content for checksum verification and
+// caching. The compression is detected by the conventional extension of Name or the leading bytes of the content, and
+// content without a known compression is copied as-is. Decompressed content is written to Writer or, if nil, to a file
+// next to Target which replaces the local file of later steps.
+type Decompress struct {
+	Name   string
+	Target string
+	Writer io.Writer
+
+	compression archive.Compression
+	header      []byte
+	tmpfile     *os.File
+	pw          *io.PipeWriter
+	done        chan error
+	finished    bool
+}
+
+var _ transfer.Step = &Decompress{}
+var _ io.Writer = &Decompress{}
+var _ transfer.CleanupStep = &Decompress{}
+
+func (dpi *Decompress) GetProgressParams() (int64, decor.Decorator) {
+	return 0, nil
+}
+
+func (dpi *Decompress) Write(p []byte) (int, error) {
+	if dpi.pw != nil {
+		return dpi.pw.Write(p)
+	}
+
+	// buffer until compression can be detected
+	dpi.header = append(dpi.header, p...)
+	if len(dpi.header) < archive.MagicSize {
+		return len(p), nil
+	}
+
+	err := dpi.start()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (dpi *Decompress) start() error {
+	dpi.compression = archive.DetectCompression(dpi.Name, dpi.header)
+
+	w := dpi.Writer
+
+	if w == nil {
+		dir, base := filepath.Split(dpi.Target)
+
+		fh, err := os.Create(filepath.Join(dir, fmt.Sprintf(".gget-%s.decompress", base)))
+		if err != nil {
+			return errors.Wrap(err, "creating decompressed file")
+		}
+
+		dpi.tmpfile = fh
+		w = fh
+	}
+
+	pr, pw := io.Pipe()
+
+	dpi.pw = pw
+	dpi.done = make(chan error, 1)
+
+	go func() {
+		dr, err := archive.NewDecompressor(dpi.compression, pr)
+		if err == nil {
+			_, err = io.Copy(w, dr)
+			dr.Close()
+		}
+
+		if err != nil {
+			pr.CloseWithError(err)
+			dpi.done <- err
+
+			return
+		}
+
+		// ignore any trailing content rather than blocking the download
+		io.Copy(ioutil.Discard, pr)
+
+		dpi.done <- nil
+	}()
+
+	header := dpi.header
+	dpi.header = nil
+
+	_, err := dpi.pw.Write(header)
+
+	return err
+}
+
+func (dpi *Decompress) Execute(_ context.Context, state *transfer.State) error {
+	if dpi.pw == nil {
+		// less content than needed for detection
+		err := dpi.start()
+		if err != nil {
+			return errors.Wrap(err, "decompressing")
+		}
+	}
+
+	dpi.pw.Close()
+
+	err := <-dpi.done
+	dpi.finished = true
+
+	if err != nil {
+		return errors.Wrap(err, "decompressing")
+	}
+
+	if dpi.tmpfile != nil {
+		err = dpi.tmpfile.Close()
+		if err != nil {
+			return errors.Wrap(err, "closing decompressed file")
+		}
+
+		if state.LocalFilePath != "" {
+			err = os.Remove(state.LocalFilePath)
+			if err != nil {
+				return errors.Wrap(err, "removing compressed file")
+			}
+		}
+
+		state.LocalFilePath = dpi.tmpfile.Name()
+	}
+
+	if dpi.compression != archive.NoCompression {
+		state.Results = append(state.Results, fmt.Sprintf("decompressed %s", dpi.compression))
+	}
+
+	return nil
+}
+
+func (dpi *Decompress) Cleanup() error {
+	if dpi.pw != nil && !dpi.finished {
+		// stop decoding an incomplete download
+		dpi.pw.CloseWithError(errors.New("transfer abandoned"))
+		<-dpi.done
+		dpi.finished = true
+	}
+
+	if dpi.tmpfile == nil {
+		return nil
+	}
+
+	dpi.tmpfile.Close()
+
+	err := os.Remove(dpi.tmpfile.Name())
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing decompressed file")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package step
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// Exec runs a shell command once the download is in place, so it should be ordered after Rename or Extract. The
+// resource is described by environment variables:
+//
+//   - GGET_LOCAL_PATH - the downloaded file (or the directory an archive was extracted to)
+//   - GGET_RESOURCE_NAME - the name of the resource
+//   - GGET_REF - the canonical ref of the repository
+//   - GGET_CHECKSUMS - the verified checksums, space-separated (e.g. sha256:abc123...)
+//   - GGET_CHECKSUM_{ALGORITHM} - the verified checksum of an algorithm (e.g. GGET_CHECKSUM_SHA256)
+//
+// Output of the command is only shown when it fails.
+type Exec struct {
+	Command  string
+	Path     string
+	Name     string
+	Ref      string
+	Verified []*checksum.HashVerifier
+}
+
+var _ transfer.Step = &Exec{}
+
+func (dpi Exec) GetProgressParams() (int64, decor.Decorator) {
+	return 1, decor.Name("running", decor.WC{W: 7, C: decor.DidentRight})
+}
+
+func (dpi Exec) Execute(ctx context.Context, state *transfer.State) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", dpi.Command)
+	cmd.Env = append(os.Environ(), dpi.Environ()...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if line := lastLine(output); line != "" {
+			return fmt.Errorf("running command: %s (%s)", err, line)
+		}
+
+		return errors.Wrap(err, "running command")
+	}
+
+	state.Results = append(state.Results, "command OK")
+
+	return nil
+}
+
+// Environ returns the variables which describe the resource to the command.
+func (dpi Exec) Environ() []string {
+	var checksums []string
+	var res []string
+
+	for _, verifier := range dpi.Verified {
+		expected := hex.EncodeToString(verifier.Expected())
+
+		checksums = append(checksums, fmt.Sprintf("%s:%s", verifier.Algorithm(), expected))
+		res = append(res, fmt.Sprintf("GGET_CHECKSUM_%s=%s", strings.ToUpper(string(verifier.Algorithm())), expected))
+	}
+
+	return append(
+		[]string{
+			fmt.Sprintf("GGET_LOCAL_PATH=%s", dpi.Path),
+			fmt.Sprintf("GGET_RESOURCE_NAME=%s", dpi.Name),
+			fmt.Sprintf("GGET_REF=%s", dpi.Ref),
+			fmt.Sprintf("GGET_CHECKSUMS=%s", strings.Join(checksums, " ")),
+		},
+		res...,
+	)
+}
+
+func lastLine(output []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+
+	return strings.TrimSpace(string(lines[len(lines)-1]))
+}
@@ -0,0 +1,87 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dpb587/gget/pkg/archive"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// Extract unpacks the downloaded archive into a directory and removes the archive. It replaces Rename since the archive
+// itself is not kept.
+type Extract struct {
+	Name      string
+	Extractor archive.Extractor
+}
+
+var _ transfer.CommitStep = &Extract{}
+
+func (dpi Extract) GetProgressParams() (int64, decor.Decorator) {
+	return 0, nil
+}
+
+func (dpi Extract) CommitTarget() string {
+	// multiple archives may be extracted into the same directory
+	return ""
+}
+
+func (dpi Extract) Execute(_ context.Context, state *transfer.State) error {
+	header, err := readHeader(state.LocalFilePath, archive.HeaderSize)
+	if err != nil {
+		return errors.Wrap(err, "reading archive")
+	}
+
+	format, compression := archive.DetectFormat(dpi.Name, header)
+	if format == archive.UnknownFormat {
+		return fmt.Errorf("unsupported archive format: %s", dpi.Name)
+	}
+
+	extractor := dpi.Extractor
+	if state.Journal != nil {
+		extractor.Rename = state.Journal.Rename
+	}
+
+	extracted, err := extractor.Extract(state.LocalFilePath, format, compression)
+	if err != nil {
+		return errors.Wrap(err, "extracting")
+	}
+
+	err = os.Remove(state.LocalFilePath)
+	if err != nil {
+		return errors.Wrap(err, "removing archive")
+	}
+
+	state.LocalFilePath = ""
+
+	ls := ""
+	if len(extracted) != 1 {
+		ls = "s"
+	}
+
+	state.Results = append(state.Results, fmt.Sprintf("extracted %d file%s", len(extracted), ls))
+
+	return nil
+}
+
+func readHeader(path string, size int) ([]byte, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	buf := make([]byte, size)
+
+	n, err := io.ReadFull(fh, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	return buf[0:n], nil
+}
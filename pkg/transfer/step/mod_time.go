@@ -0,0 +1,32 @@
+package step
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// ModTime sets the modification time of the downloaded file (e.g. to when the remote content changed). It should be
+// ordered before Rename.
+type ModTime struct {
+	ModTime time.Time
+}
+
+var _ transfer.Step = &ModTime{}
+
+func (dpi ModTime) GetProgressParams() (int64, decor.Decorator) {
+	return 0, nil
+}
+
+func (dpi ModTime) Execute(_ context.Context, state *transfer.State) error {
+	err := os.Chtimes(state.LocalFilePath, dpi.ModTime, dpi.ModTime)
+	if err != nil {
+		return errors.Wrap(err, "setting modification time")
+	}
+
+	return nil
+}
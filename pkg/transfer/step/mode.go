@@ -0,0 +1,37 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// Mode sets the permissions of the downloaded file rather than relying on the umask. It should be ordered before Rename.
+type Mode struct {
+	Mode os.FileMode
+}
+
+var _ transfer.Step = &Mode{}
+
+func (dpi Mode) GetProgressParams() (int64, decor.Decorator) {
+	return 0, nil
+}
+
+func (dpi Mode) Execute(_ context.Context, state *transfer.State) error {
+	err := os.Chmod(state.LocalFilePath, dpi.Mode)
+	if err != nil {
+		return errors.Wrap(err, "chmod'ing")
+	}
+
+	if dpi.Mode == 0755 {
+		state.Results = append(state.Results, "executable")
+	} else {
+		state.Results = append(state.Results, fmt.Sprintf("mode %04o", dpi.Mode))
+	}
+
+	return nil
+}
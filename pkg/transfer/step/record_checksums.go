@@ -0,0 +1,65 @@
+package step
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/pkg/errors"
+	"github.com/vbauerster/mpb/v4/decor"
+)
+
+// RecordChecksums adds the checksums of the downloaded content to a manager, including any which were verified. It
+// should be ordered after any checksum verification.
+type RecordChecksums struct {
+	Manager  checksum.WriteableManager
+	Resource string
+	Verified []*checksum.HashVerifier
+
+	hasher hash.Hash
+}
+
+var _ transfer.Step = &RecordChecksums{}
+var _ io.Writer = &RecordChecksums{}
+
+func (dpi *RecordChecksums) GetProgressParams() (int64, decor.Decorator) {
+	return 0, nil
+}
+
+func (dpi *RecordChecksums) Write(p []byte) (int, error) {
+	if dpi.hasher == nil {
+		dpi.hasher = sha256.New()
+	}
+
+	return dpi.hasher.Write(p)
+}
+
+func (dpi *RecordChecksums) Execute(_ context.Context, _ *transfer.State) error {
+	if dpi.hasher == nil {
+		dpi.hasher = sha256.New()
+	}
+
+	var computed bool
+
+	for _, verifier := range dpi.Verified {
+		cs, err := checksum.GuessChecksum(verifier.Expected())
+		if err != nil {
+			return errors.Wrapf(err, "recording %s checksum", verifier.Algorithm())
+		}
+
+		dpi.Manager.AddChecksum(dpi.Resource, cs)
+
+		if verifier.Algorithm() == checksum.SHA256 {
+			computed = true
+		}
+	}
+
+	if !computed {
+		dpi.Manager.AddChecksum(dpi.Resource, checksum.NewHashChecksum(checksum.SHA256, dpi.hasher.Sum(nil), sha256.New))
+	}
+
+	return nil
+}
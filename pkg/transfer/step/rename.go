@@ -13,14 +13,23 @@ type Rename struct {
 	Target string
 }
 
-var _ transfer.Step = &Rename{}
+var _ transfer.CommitStep = &Rename{}
 
 func (dpi Rename) GetProgressParams() (int64, decor.Decorator) {
 	return 0, nil
 }
 
+func (dpi Rename) CommitTarget() string {
+	return dpi.Target
+}
+
 func (dpi Rename) Execute(_ context.Context, state *transfer.State) error {
-	err := os.Rename(state.LocalFilePath, dpi.Target)
+	rename := os.Rename
+	if state.Journal != nil {
+		rename = state.Journal.Rename
+	}
+
+	err := rename(state.LocalFilePath, dpi.Target)
 	if err != nil {
 		return errors.Wrap(err, "renaming")
 	}
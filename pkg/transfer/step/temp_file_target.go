@@ -2,48 +2,219 @@ package step
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
+	"github.com/dpb587/gget/pkg/fsutil"
 	"github.com/dpb587/gget/pkg/transfer"
 	"github.com/pkg/errors"
 	"github.com/vbauerster/mpb/v4/decor"
 )
 
+// TempFileTarget writes to a partial file next to the target which may be resumed by later attempts.
 type TempFileTarget struct {
-	Tmpdir string
+	Target string
 
 	tmpfile *os.File
 }
 
 var _ transfer.Step = &TempFileTarget{}
-var _ io.Writer = &TempFileTarget{}
+var _ transfer.SegmentTarget = &TempFileTarget{}
+var _ transfer.LinkTarget = &TempFileTarget{}
+var _ transfer.CleanupStep = &TempFileTarget{}
 
 func (dpi *TempFileTarget) GetProgressParams() (int64, decor.Decorator) {
 	return 0, nil
 }
 
+// Path is the deterministic name of the partial file.
+func (dpi *TempFileTarget) Path() string {
+	dir, base := filepath.Split(dpi.Target)
+
+	return filepath.Join(dir, fmt.Sprintf(".gget-%s.partial", base))
+}
+
+// mkdir creates missing parent directories of the target (e.g. for nested repository paths).
+func (dpi *TempFileTarget) mkdir() error {
+	err := os.MkdirAll(filepath.Dir(dpi.Target), 0755)
+	if err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	return nil
+}
+
+func (dpi *TempFileTarget) validatorPath() string {
+	return fmt.Sprintf("%s-validator", dpi.Path())
+}
+
+func (dpi *TempFileTarget) Resume() (int64, string, error) {
+	validator, err := ioutil.ReadFile(dpi.validatorPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, "", nil
+		}
+
+		return 0, "", errors.Wrap(err, "reading validator")
+	}
+
+	fh, err := os.OpenFile(dpi.Path(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, "", nil
+		}
+
+		return 0, "", errors.Wrap(err, "opening partial file")
+	}
+
+	fi, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+
+		return 0, "", errors.Wrap(err, "checking partial file")
+	}
+
+	dpi.tmpfile = fh
+
+	return fi.Size(), string(validator), nil
+}
+
+func (dpi *TempFileTarget) OpenRetained() (io.ReadCloser, error) {
+	return os.Open(dpi.Path())
+}
+
+func (dpi *TempFileTarget) Reset(validator string) error {
+	if dpi.tmpfile != nil {
+		dpi.tmpfile.Close()
+		dpi.tmpfile = nil
+	}
+
+	err := dpi.mkdir()
+	if err != nil {
+		return err
+	}
+
+	fh, err := os.Create(dpi.Path())
+	if err != nil {
+		return errors.Wrap(err, "creating partial file")
+	}
+
+	dpi.tmpfile = fh
+
+	if validator == "" {
+		err = os.Remove(dpi.validatorPath())
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing validator")
+		}
+
+		return nil
+	}
+
+	err = ioutil.WriteFile(dpi.validatorPath(), []byte(validator), 0644)
+	if err != nil {
+		return errors.Wrap(err, "writing validator")
+	}
+
+	return nil
+}
+
+func (dpi *TempFileTarget) Link(path string) error {
+	if dpi.tmpfile != nil {
+		dpi.tmpfile.Close()
+		dpi.tmpfile = nil
+	}
+
+	for _, p := range []string{dpi.Path(), dpi.validatorPath()} {
+		err := os.Remove(p)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing partial file")
+		}
+	}
+
+	err := dpi.mkdir()
+	if err != nil {
+		return err
+	}
+
+	// a copy since later steps may change the file (e.g. its mode) which must not affect the source
+	err = fsutil.Copy(path, dpi.Path())
+	if err != nil {
+		return errors.Wrap(err, "copying partial file")
+	}
+
+	err = os.Chmod(dpi.Path(), 0644)
+	if err != nil {
+		return errors.Wrap(err, "changing partial file mode")
+	}
+
+	fh, err := os.OpenFile(dpi.Path(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "opening partial file")
+	}
+
+	dpi.tmpfile = fh
+
+	return nil
+}
+
 func (dpi *TempFileTarget) Write(p []byte) (int, error) {
 	if dpi.tmpfile == nil {
-		p, err := ioutil.TempFile(dpi.Tmpdir, ".gget-*")
-		if err != nil {
-			return 0, errors.Wrap(err, "creating tempfile")
+		if err := dpi.Reset(""); err != nil {
+			return 0, err
 		}
-
-		dpi.tmpfile = p
 	}
 
 	return dpi.tmpfile.Write(p)
 }
 
+func (dpi *TempFileTarget) WriteAt(p []byte, off int64) (int, error) {
+	if dpi.tmpfile == nil {
+		return 0, errors.New("partial file was not reset")
+	}
+
+	return dpi.tmpfile.WriteAt(p, off)
+}
+
 func (dpi *TempFileTarget) Execute(_ context.Context, s *transfer.State) error {
+	if dpi.tmpfile == nil {
+		// nothing was written
+		if err := dpi.Reset(""); err != nil {
+			return err
+		}
+	}
+
 	err := dpi.tmpfile.Close()
 	if err != nil {
 		return errors.Wrap(err, "closing file")
 	}
 
+	// download is complete; nothing to resume
+	err = os.Remove(dpi.validatorPath())
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing validator")
+	}
+
 	s.LocalFilePath = dpi.tmpfile.Name()
 
 	return nil
 }
+
+// Cleanup removes the partial file rather than keeping it for a later attempt.
+func (dpi *TempFileTarget) Cleanup() error {
+	if dpi.tmpfile != nil {
+		dpi.tmpfile.Close()
+		dpi.tmpfile = nil
+	}
+
+	for _, p := range []string{dpi.Path(), dpi.validatorPath()} {
+		err := os.Remove(p)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing partial file")
+		}
+	}
+
+	return nil
+}
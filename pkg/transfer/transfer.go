@@ -4,27 +4,60 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/dpb587/gget/pkg/retry"
 	"github.com/pkg/errors"
 	"github.com/vbauerster/mpb/v4"
 	"github.com/vbauerster/mpb/v4/decor"
+	"golang.org/x/time/rate"
 )
 
 type Transfer struct {
-	origin      DownloadAsset
-	steps       []Step
-	finalStatus io.Writer
+	origin DownloadAsset
+	steps  []Step
+	opts   Options
 
-	pb   *mpb.Progress
-	bars []*mpb.Bar
+	pb      *mpb.Progress
+	bars    []*mpb.Bar
+	retries *int32
+
+	// deferred is set by a batch which commits its transfers together.
+	deferred *deferredCommit
+
+	// rateLimiter is set by a batch which limits the throughput of its transfers.
+	rateLimiter *rate.Limiter
+}
+
+// deferredCommit is the remaining steps of a transfer, starting from its first CommitStep.
+type deferredCommit struct {
+	steps []Step
+	state State
+}
+
+type Options struct {
+	FinalStatus io.Writer
+	RetryPolicy retry.Policy
+
+	// Segments is the maximum number of concurrent range requests for downloading the origin.
+	Segments int
+
+	// StallTimeout is the maximum duration without receiving content before a download is retried (if positive).
+	StallTimeout time.Duration
+
+	// UpToDate is checked before downloading; the transfer does nothing else when the target is already up to date.
+	UpToDate func(ctx context.Context) (bool, error)
 }
 
-func NewTransfer(origin DownloadAsset, steps []Step, finalStatus io.Writer) *Transfer {
+func NewTransfer(origin DownloadAsset, steps []Step, opts Options) *Transfer {
 	return &Transfer{
-		origin:      origin,
-		steps:       steps,
-		finalStatus: finalStatus,
+		origin:  origin,
+		steps:   steps,
+		opts:    opts,
+		retries: new(int32),
 	}
 }
 
@@ -55,7 +88,14 @@ func (w *Transfer) Prepare(pb *mpb.Progress) {
 	w.bars[2] = w.newBar(pb, w.bars[1], "", downloadSize, decor.OnComplete(
 		downloadDecor,
 		"downloaded",
-	))
+	), decor.Any(func(_ *decor.Statistics) string {
+		retries := atomic.LoadInt32(w.retries)
+		if retries == 0 {
+			return ""
+		}
+
+		return fmt.Sprintf("(retry %d of %d)", retries, w.opts.RetryPolicy.MaxAttempts-1)
+	}))
 
 	lastBar := w.bars[2]
 
@@ -80,60 +120,57 @@ func (w Transfer) Execute(ctx context.Context) error {
 		w.bars[0].SetTotal(1, true)
 	}
 
-	var assetHandle io.ReadCloser
-
-	{ // connecting
-		var err error
+	ctx = retry.WithObserver(ctx, func(_ int, _ error) {
+		atomic.AddInt32(w.retries, 1)
+	})
 
-		assetHandle, err = w.origin.Open(ctx)
+	if w.opts.UpToDate != nil {
+		upToDate, err := w.opts.UpToDate(ctx)
 		if err != nil {
-			return errors.Wrap(err, "connecting")
-		}
+			return err
+		} else if upToDate {
+			w.finalize("√", "up to date")
 
-		w.bars[1].SetTotal(1, true)
+			return nil
+		}
 	}
 
-	defer assetHandle.Close()
+	var results []string
 
 	{ // downloading
-		r := w.bars[2].ProxyReader(assetHandle)
-		defer r.Close()
-
-		var dw io.Writer
-
-		{ // enumerate writers
-			var writers []io.Writer
-
-			for _, step := range w.steps {
-				writer, ok := step.(io.Writer)
-				if !ok {
-					continue
-				}
-
-				writers = append(writers, writer)
-			}
-
-			if len(writers) == 0 {
-				return fmt.Errorf("no download target found")
-			}
-
-			dw = io.MultiWriter(writers...)
+		ds, err := w.newDownloadState()
+		if err != nil {
+			return err
 		}
 
-		_, err := io.Copy(dw, r)
+		err = w.downloadAll(ctx, ds)
 		if err != nil {
-			return errors.Wrap(err, "downloading")
+			return err
 		}
 
 		w.bars[2].SetTotal(int64(w.origin.GetSize()), true)
+
+		if ds.resumed {
+			results = append(results, "resumed")
+		} else if ds.linked {
+			results = append(results, "cached")
+		}
 	}
 
-	var results []string
+	var deferred bool
 
 	{ // stepwise
 		state := State{}
 
 		for stepIdx, step := range w.steps {
+			if _, ok := step.(CommitStep); ok && w.deferred != nil {
+				w.deferred.steps = w.steps[stepIdx:]
+				w.deferred.state = state
+				deferred = true
+
+				break
+			}
+
 			state.Bar = w.bars[stepIdx+3]
 
 			err := step.Execute(ctx, &state)
@@ -147,11 +184,15 @@ func (w Transfer) Execute(ctx context.Context) error {
 			}
 		}
 
-		results = state.Results
+		results = append(results, state.Results...)
 	}
 
 	{ // done
 		summary := fmt.Sprintf("done")
+		if deferred {
+			summary = fmt.Sprintf("ready")
+		}
+
 		if len(results) > 0 {
 			summary = fmt.Sprintf("%s (%s)", summary, strings.Join(results, "; "))
 		}
@@ -162,6 +203,69 @@ func (w Transfer) Execute(ctx context.Context) error {
 	return nil
 }
 
+// commitTarget is the path changed by the first CommitStep, if any.
+func (w Transfer) commitTarget() string {
+	for _, step := range w.steps {
+		if commitStep, ok := step.(CommitStep); ok {
+			target := commitStep.CommitTarget()
+			if target == "" {
+				return ""
+			}
+
+			return filepath.Clean(target)
+		}
+	}
+
+	return ""
+}
+
+// commit executes any deferred steps, recording replaced files in the journal.
+func (w Transfer) commit(ctx context.Context, journal *Journal) error {
+	if w.deferred == nil {
+		return nil
+	}
+
+	steps := w.deferred.steps
+	w.deferred.steps = nil
+	w.deferred.state.Journal = journal
+
+	for stepIdx, step := range steps {
+		err := step.Execute(ctx, &w.deferred.state)
+		if err != nil {
+			return errors.Wrapf(err, "processing step %d", len(w.steps)-len(steps)+stepIdx)
+		}
+	}
+
+	return nil
+}
+
+// discard removes the temporary files of any steps which were not committed.
+func (w Transfer) discard() error {
+	if w.deferred != nil {
+		w.deferred.steps = nil
+	}
+
+	var errs []string
+
+	for _, step := range w.steps {
+		cleanupStep, ok := step.(CleanupStep)
+		if !ok {
+			continue
+		}
+
+		err := cleanupStep.Cleanup()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cleaning up: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
 func (w Transfer) finalize(status, description string) {
 	w.bars[len(w.steps)+4] = w.newBar(w.pb, w.bars[len(w.steps)+3], status, 1, decor.Name(
 		description,
@@ -177,12 +281,12 @@ func (w Transfer) finalize(status, description string) {
 		bar.SetTotal(1, true)
 	}
 
-	if w.finalStatus != nil {
-		fmt.Fprintf(w.finalStatus, "%s %s\n", w.GetSubject(), description)
+	if w.opts.FinalStatus != nil {
+		fmt.Fprintf(w.opts.FinalStatus, "%s %s\n", w.GetSubject(), description)
 	}
 }
 
-func (w Transfer) newBar(pb *mpb.Progress, pbp *mpb.Bar, spinner string, count int64, msg decor.Decorator) *mpb.Bar {
+func (w Transfer) newBar(pb *mpb.Progress, pbp *mpb.Bar, spinner string, count int64, msgs ...decor.Decorator) *mpb.Bar {
 	var spinnerd decor.Decorator
 
 	switch spinner {
@@ -204,12 +308,16 @@ func (w Transfer) newBar(pb *mpb.Progress, pbp *mpb.Bar, spinner string, count i
 		count,
 		mpb.BarParkTo(pbp),
 		mpb.PrependDecorators(
-			spinnerd,
-			decor.Name(
-				subject,
-				decor.WC{W: len(subject), C: decor.DSyncSpaceR},
-			),
-			msg,
+			append(
+				[]decor.Decorator{
+					spinnerd,
+					decor.Name(
+						subject,
+						decor.WC{W: len(subject), C: decor.DSyncSpaceR},
+					),
+				},
+				msgs...,
+			)...,
 		),
 	)
 }
@@ -0,0 +1,207 @@
+package transfer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vbauerster/mpb/v4"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/httputil"
+	"github.com/dpb587/gget/pkg/retry"
+	. "github.com/dpb587/gget/pkg/transfer"
+	"github.com/dpb587/gget/pkg/transfer/step"
+)
+
+type testOrigin struct {
+	url  string
+	size int64
+}
+
+func (o testOrigin) GetName() string {
+	return "test-file"
+}
+
+func (o testOrigin) GetSize() int64 {
+	return o.size
+}
+
+func (o testOrigin) Open(ctx context.Context) (io.ReadCloser, error) {
+	return httputil.Get(ctx, http.DefaultClient, o.url)
+}
+
+func (o testOrigin) OpenRange(ctx context.Context, offset, length int64, validator string) (*httputil.RangeReader, error) {
+	return httputil.GetRange(ctx, http.DefaultClient, o.url, offset, length, validator)
+}
+
+var _ = Describe("Transfer", func() {
+	var server *httptest.Server
+	var content []byte
+	var requestedRanges []string
+	var requestedRangesM sync.Mutex
+	var tmpdir string
+	var segments int
+	var stallTimeout time.Duration
+
+	BeforeEach(func() {
+		content = bytes.Repeat([]byte("0123456789"), 1024)
+		requestedRanges = nil
+		segments = 1
+		stallTimeout = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedRangesM.Lock()
+			requestedRanges = append(requestedRanges, r.Header.Get("range"))
+			requestedRangesM.Unlock()
+
+			w.Header().Set("etag", `"v1"`)
+			http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+		}))
+
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-transfer-test-")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	execute := func(target string, extraSteps ...Step) error {
+		sum := sha256.Sum256(content)
+
+		steps := []Step{
+			&step.TempFileTarget{Target: target},
+			&step.VerifyChecksum{Verifier: checksum.NewHashVerifier(checksum.SHA256, sum[:], sha256.New())},
+		}
+
+		steps = append(steps, extraSteps...)
+		steps = append(steps, &step.Rename{Target: target})
+
+		xfer := NewTransfer(testOrigin{url: server.URL, size: int64(len(content))}, steps, Options{RetryPolicy: retry.NewPolicy(0), Segments: segments, StallTimeout: stallTimeout})
+		xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+
+		return xfer.Execute(context.Background())
+	}
+
+	It("downloads", func() {
+		target := filepath.Join(tmpdir, "result")
+
+		Expect(execute(target)).To(Succeed())
+
+		buf, err := ioutil.ReadFile(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf).To(Equal(content))
+
+		matches, _ := filepath.Glob(filepath.Join(tmpdir, ".gget-*"))
+		Expect(matches).To(BeEmpty())
+	})
+
+	It("resumes a partial download", func() {
+		target := filepath.Join(tmpdir, "result")
+
+		Expect(ioutil.WriteFile(filepath.Join(tmpdir, ".gget-result.partial"), content[0:4000], 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tmpdir, ".gget-result.partial-validator"), []byte(`"v1"`), 0644)).To(Succeed())
+
+		Expect(execute(target)).To(Succeed())
+		Expect(requestedRanges).To(Equal([]string{"bytes=4000-"}))
+
+		buf, err := ioutil.ReadFile(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf).To(Equal(content))
+	})
+
+	It("restarts a partial download of different content", func() {
+		target := filepath.Join(tmpdir, "result")
+
+		Expect(ioutil.WriteFile(filepath.Join(tmpdir, ".gget-result.partial"), []byte("stale"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tmpdir, ".gget-result.partial-validator"), []byte(`"v0"`), 0644)).To(Succeed())
+
+		Expect(execute(target)).To(Succeed())
+
+		buf, err := ioutil.ReadFile(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf).To(Equal(content))
+	})
+
+	It("downloads in segments", func() {
+		content = bytes.Repeat([]byte("0123456789"), 300*1024)
+		segments = 4
+
+		target := filepath.Join(tmpdir, "result")
+
+		Expect(execute(target)).To(Succeed())
+
+		sort.Strings(requestedRanges)
+		// limited by the minimum segment size
+		Expect(requestedRanges).To(Equal([]string{"bytes=0-1535999", "bytes=1536000-3071999"}))
+
+		buf, err := ioutil.ReadFile(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf).To(Equal(content))
+	})
+
+	It("decompresses while verifying the original content", func() {
+		decompressed := content
+
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		gw.Write(decompressed)
+		gw.Close()
+
+		content = buf.Bytes()
+
+		target := filepath.Join(tmpdir, "result")
+
+		Expect(execute(target, &step.Decompress{Name: "result.gz", Target: target})).To(Succeed())
+
+		actual, err := ioutil.ReadFile(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(decompressed))
+
+		matches, _ := filepath.Glob(filepath.Join(tmpdir, ".gget-*"))
+		Expect(matches).To(BeEmpty())
+	})
+
+	It("copies content without a known compression", func() {
+		target := filepath.Join(tmpdir, "result")
+
+		Expect(execute(target, &step.Decompress{Name: "result", Target: target})).To(Succeed())
+
+		actual, err := ioutil.ReadFile(target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(content))
+	})
+
+	It("fails a stalled download", func() {
+		stallTimeout = 100 * time.Millisecond
+
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-length", strconv.Itoa(len(content)))
+			w.Write(content[0:1024])
+			w.(http.Flusher).Flush()
+
+			<-r.Context().Done()
+		})
+
+		err := execute(filepath.Join(tmpdir, "result"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("stalled (no content received for 100ms)"))
+	})
+})
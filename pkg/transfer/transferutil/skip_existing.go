@@ -0,0 +1,131 @@
+package transferutil
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/transfer"
+	"github.com/dpb587/gget/pkg/transfer/step"
+	"github.com/pkg/errors"
+)
+
+// existingFileChecker compares an existing target file with what would be downloaded. Checksums are still recorded
+// for a file which is kept, as if it had been downloaded, but only if the file was verified against those of the origin.
+type existingFileChecker struct {
+	mode       SkipMode
+	targetPath string
+	size       int64
+	verifiers  []*checksum.HashVerifier
+	recorder   checksum.WriteableManager
+	resource   string
+}
+
+func (c existingFileChecker) UpToDate(ctx context.Context) (bool, error) {
+	fi, err := os.Stat(c.targetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, "checking existing file")
+	} else if !fi.Mode().IsRegular() {
+		return false, nil
+	}
+
+	var verified []*checksum.HashVerifier
+
+	switch c.mode {
+	case AlwaysSkip:
+		// any file
+	case SizeSkip:
+		if c.size == 0 || fi.Size() != c.size {
+			return false, nil
+		}
+	case ChecksumSkip:
+		if len(c.verifiers) == 0 {
+			// nothing to compare with
+			return false, nil
+		} else if c.size > 0 && fi.Size() != c.size {
+			return false, nil
+		}
+
+		for _, verifier := range c.verifiers {
+			// the original verifiers are still needed if the file is downloaded
+			cs, err := checksum.GuessChecksum(verifier.Expected())
+			if err != nil {
+				return false, errors.Wrapf(err, "getting %s verifier", verifier.Algorithm())
+			}
+
+			fresh, err := cs.NewVerifier(ctx)
+			if err != nil {
+				return false, errors.Wrapf(err, "getting %s verifier", verifier.Algorithm())
+			}
+
+			verified = append(verified, fresh)
+		}
+	default:
+		return false, nil
+	}
+
+	if len(verified) == 0 {
+		// nothing was verified, so nothing can be recorded
+		return true, nil
+	}
+
+	var writers []io.Writer
+	var steps []transfer.Step
+
+	for _, verifier := range verified {
+		vs := &step.VerifyChecksum{Verifier: verifier}
+		writers = append(writers, vs)
+		steps = append(steps, vs)
+	}
+
+	if c.recorder != nil {
+		rs := &step.RecordChecksums{
+			Manager:  c.recorder,
+			Resource: c.resource,
+			Verified: verified,
+		}
+		writers = append(writers, rs)
+		steps = append(steps, rs)
+	}
+
+	err = c.hash(writers)
+	if err != nil {
+		return false, err
+	}
+
+	state := transfer.State{}
+
+	for _, s := range steps {
+		err = s.Execute(ctx, &state)
+		if err != nil {
+			if _, ok := err.(*checksum.HashVerificationError); ok {
+				return false, nil
+			}
+
+			return false, errors.Wrap(err, "checking existing file")
+		}
+	}
+
+	return true, nil
+}
+
+func (c existingFileChecker) hash(writers []io.Writer) error {
+	fh, err := os.Open(c.targetPath)
+	if err != nil {
+		return errors.Wrap(err, "opening existing file")
+	}
+
+	defer fh.Close()
+
+	_, err = io.Copy(io.MultiWriter(writers...), fh)
+	if err != nil {
+		return errors.Wrap(err, "reading existing file")
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package transferutil
+
+import "fmt"
+
+type SkipMode string
+
+// NoSkip always downloads, replacing any existing file.
+const NoSkip SkipMode = ""
+
+// ChecksumSkip keeps an existing file when it matches the checksums which would have verified the download.
+const ChecksumSkip SkipMode = "checksum"
+
+// SizeSkip keeps an existing file when it has the size of the download.
+const SizeSkip SkipMode = "size"
+
+// AlwaysSkip keeps any existing file.
+const AlwaysSkip SkipMode = "always"
+
+func ParseSkipMode(in string) (SkipMode, error) {
+	switch SkipMode(in) {
+	case ChecksumSkip, SizeSkip, AlwaysSkip:
+		return SkipMode(in), nil
+	}
+
+	return "", fmt.Errorf("unsupported skip mode: %s", in)
+}
@@ -2,12 +2,16 @@ package transferutil
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"time"
 
+	"github.com/dpb587/gget/pkg/archive"
+	"github.com/dpb587/gget/pkg/cache"
 	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/retry"
 	"github.com/dpb587/gget/pkg/service"
 	"github.com/dpb587/gget/pkg/transfer"
 	"github.com/dpb587/gget/pkg/transfer/step"
@@ -17,7 +21,21 @@ import (
 func BuildTransfer(ctx context.Context, origin transfer.DownloadAsset, targetPath string, opts TransferOptions) (*transfer.Transfer, error) {
 	var steps []transfer.Step
 
-	if targetPath == "-" {
+	if targetPath == "-" && opts.Extract != nil {
+		return nil, fmt.Errorf("cannot extract archive to stdout")
+	} else if targetPath == "-" && len(opts.Exec) > 0 {
+		return nil, fmt.Errorf("cannot run commands for stdout")
+	}
+
+	if targetPath == "-" && opts.Decompress {
+		steps = append(
+			steps,
+			&step.Decompress{
+				Name:   origin.GetName(),
+				Writer: os.Stdout,
+			},
+		)
+	} else if targetPath == "-" {
 		steps = append(
 			steps,
 			&step.WriterTarget{
@@ -28,15 +46,19 @@ func BuildTransfer(ctx context.Context, origin transfer.DownloadAsset, targetPat
 		steps = append(
 			steps,
 			&step.TempFileTarget{
-				Tmpdir: filepath.Dir(targetPath),
+				Target: targetPath,
 			},
 		)
 	}
 
+	var verifiers []*checksum.HashVerifier
+
 	if len(opts.ChecksumVerification.Acceptable) > 0 {
 		var csl checksum.ChecksumList
 
-		if csr, ok := origin.(service.ChecksumSupportedResolvedResource); ok {
+		if opts.Checksums != nil {
+			csl = opts.ChecksumVerification.Selector.SelectChecksums(opts.Checksums.FilterAlgorithms(opts.ChecksumVerification.Acceptable))
+		} else if csr, ok := origin.(service.ChecksumSupportedResolvedResource); ok {
 			avail, err := csr.GetChecksums(ctx, opts.ChecksumVerification.Acceptable)
 			if err != nil {
 				return nil, errors.Wrap(err, "getting checksum")
@@ -55,36 +77,219 @@ func BuildTransfer(ctx context.Context, origin transfer.DownloadAsset, targetPat
 				return nil, errors.Wrapf(err, "getting %s verifier", cs.Algorithm())
 			}
 
+			verifiers = append(verifiers, verifier)
+		}
+	}
+
+	var modTime time.Time
+
+	if opts.PreserveModTime && targetPath != "-" && opts.Extract == nil {
+		if mtr, ok := origin.(service.ModTimeResolvedResource); ok {
+			var err error
+
+			modTime, err = mtr.GetModTime(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "getting modification time")
+			}
+		}
+	}
+
+	var cacheKeys []string
+
+	if opts.Cache != nil {
+		for _, verifier := range verifiers {
+			cacheKeys = append(cacheKeys, cache.ChecksumKey(verifier.Algorithm(), verifier.Expected()))
+		}
+
+		if opts.CacheKey != "" {
+			cacheKeys = append(cacheKeys, opts.CacheKey)
+		}
+	}
+
+	var cached bool
+
+	if opts.Cache != nil && opts.CacheMode.Readable() {
+		entry, found, err := opts.Cache.Lookup(cacheKeys...)
+		if err != nil {
+			return nil, errors.Wrap(err, "checking cache")
+		} else if found {
+			origin = cache.NewResource(origin.GetName(), *entry)
+			cached = true
+
+			if len(verifiers) == 0 {
+				// still detect cached files which changed since they were stored
+				verifier, err := checksum.NewHashChecksum(checksum.SHA256, entry.SHA256, sha256.New).NewVerifier(ctx)
+				if err != nil {
+					return nil, errors.Wrap(err, "getting cache verifier")
+				}
+
+				verifiers = append(verifiers, verifier)
+			}
+		} else if opts.CacheRequired {
+			return nil, fmt.Errorf("resource not found in cache: %s", origin.GetName())
+		}
+	}
+
+	for _, verifier := range verifiers {
+		steps = append(
+			steps,
+			&step.VerifyChecksum{
+				Verifier: verifier,
+			},
+		)
+	}
+
+	if opts.ChecksumRecorder != nil {
+		steps = append(
+			steps,
+			&step.RecordChecksums{
+				Manager:  opts.ChecksumRecorder,
+				Resource: origin.GetName(),
+				Verified: verifiers,
+			},
+		)
+	}
+
+	if targetPath != "-" {
+		if opts.Cache != nil && opts.CacheMode.Writable() && !cached {
 			steps = append(
 				steps,
-				&step.VerifyChecksum{
-					Verifier: verifier,
+				&step.CacheStore{
+					Cache: opts.Cache,
+					Keys:  cacheKeys,
 				},
 			)
 		}
-	}
 
-	if targetPath != "-" {
-		if opts.Executable {
+		if opts.Decompress {
 			steps = append(
 				steps,
-				&step.Executable{},
+				&step.Decompress{
+					Name:   origin.GetName(),
+					Target: targetPath,
+				},
 			)
 		}
 
-		steps = append(
-			steps,
-			&step.Rename{
-				Target: targetPath,
-			},
-		)
+		if opts.Extract != nil {
+			steps = append(
+				steps,
+				&step.Extract{
+					Name:      origin.GetName(),
+					Extractor: *opts.Extract,
+				},
+			)
+		} else {
+			if opts.Mode != 0 {
+				steps = append(
+					steps,
+					&step.Mode{
+						Mode: opts.Mode,
+					},
+				)
+			}
+
+			if !modTime.IsZero() {
+				steps = append(
+					steps,
+					&step.ModTime{
+						ModTime: modTime,
+					},
+				)
+			}
+
+			steps = append(
+				steps,
+				&step.Rename{
+					Target: targetPath,
+				},
+			)
+		}
+
+		localPath := targetPath
+		if opts.Extract != nil {
+			localPath = opts.Extract.Dir
+		}
+
+		for _, command := range opts.Exec {
+			steps = append(
+				steps,
+				&step.Exec{
+					Command:  command,
+					Path:     localPath,
+					Name:     origin.GetName(),
+					Ref:      opts.Ref,
+					Verified: verifiers,
+				},
+			)
+		}
+	}
+
+	xferOpts := transfer.Options{
+		FinalStatus:  opts.FinalStatus,
+		RetryPolicy:  opts.RetryPolicy,
+		Segments:     opts.Segments,
+		StallTimeout: opts.StallTimeout,
+	}
+
+	if targetPath != "-" && opts.Extract == nil && opts.SkipExisting != NoSkip {
+		// decompressed files no longer have the checksums or size of the download
+		if !opts.Decompress || opts.SkipExisting == AlwaysSkip {
+			xferOpts.UpToDate = existingFileChecker{
+				mode:       opts.SkipExisting,
+				targetPath: targetPath,
+				size:       origin.GetSize(),
+				verifiers:  verifiers,
+				recorder:   opts.ChecksumRecorder,
+				resource:   origin.GetName(),
+			}.UpToDate
+		}
 	}
 
-	return transfer.NewTransfer(origin, steps, opts.FinalStatus), nil
+	return transfer.NewTransfer(origin, steps, xferOpts), nil
 }
 
 type TransferOptions struct {
-	Executable           bool
 	ChecksumVerification checksum.VerificationProfile
 	FinalStatus          io.Writer
+	RetryPolicy          retry.Policy
+	Segments             int
+	StallTimeout         time.Duration
+
+	// Checksums replaces any checksums published by the origin (e.g. from a lock file).
+	Checksums checksum.ChecksumList
+
+	// ChecksumRecorder receives the checksums of the content after it has been verified.
+	ChecksumRecorder checksum.WriteableManager
+
+	// Cache is used according to CacheMode; CacheKey should identify the origin (see cache.ResourceKey).
+	Cache     *cache.Cache
+	CacheMode cache.Mode
+	CacheKey  string
+
+	// CacheRequired fails rather than downloading from the origin (e.g. offline).
+	CacheRequired bool
+
+	// Decompress writes the decompressed content to the target path; checksums are still verified against the original.
+	Decompress bool
+
+	// Extract unpacks the downloaded archive instead of keeping it at the target path.
+	Extract *archive.Extractor
+
+	// Mode sets the permissions of the downloaded file (otherwise the umask applies). It is not used for extracted
+	// archives.
+	Mode os.FileMode
+
+	// PreserveModTime sets the modification time of the downloaded file to when the origin changed, if known. It is not
+	// used for extracted archives.
+	PreserveModTime bool
+
+	// Exec commands are run in order once the download is in place, but not for existing files which are kept. Ref is
+	// the canonical ref of the origin and provided to them.
+	Exec []string
+	Ref  string
+
+	// SkipExisting keeps an existing target file, rather than downloading, when it matches according to the mode. It is
+	// not supported for extracted archives, and decompressed files are only kept with AlwaysSkip.
+	SkipExisting SkipMode
 }
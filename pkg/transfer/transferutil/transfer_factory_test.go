@@ -0,0 +1,296 @@
+package transferutil_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vbauerster/mpb/v4"
+
+	"github.com/dpb587/gget/pkg/cache"
+	"github.com/dpb587/gget/pkg/checksum"
+	"github.com/dpb587/gget/pkg/retry"
+	. "github.com/dpb587/gget/pkg/transfer/transferutil"
+)
+
+type testOrigin struct {
+	content []byte
+	opened  *int
+}
+
+func (o testOrigin) GetName() string {
+	return "test-file"
+}
+
+func (o testOrigin) GetSize() int64 {
+	return int64(len(o.content))
+}
+
+func (o testOrigin) Open(_ context.Context) (io.ReadCloser, error) {
+	*o.opened++
+
+	return ioutil.NopCloser(bytes.NewReader(o.content)), nil
+}
+
+type modTimeOrigin struct {
+	testOrigin
+	modTime time.Time
+}
+
+func (o modTimeOrigin) GetModTime(_ context.Context) (time.Time, error) {
+	return o.modTime, nil
+}
+
+var _ = Describe("BuildTransfer", func() {
+	var tmpdir, target string
+	var origin testOrigin
+	var recorder checksum.WriteableManager
+
+	BeforeEach(func() {
+		var err error
+
+		tmpdir, err = ioutil.TempDir("", "gget-transferutil-test-")
+		Expect(err).ToNot(HaveOccurred())
+
+		target = filepath.Join(tmpdir, "result")
+		origin = testOrigin{content: []byte("expected content"), opened: new(int)}
+		recorder = checksum.NewInMemoryManager()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpdir)
+	})
+
+	execute := func(mode SkipMode) {
+		sum := sha256.Sum256(origin.content)
+
+		xfer, err := BuildTransfer(context.Background(), origin, target, TransferOptions{
+			ChecksumVerification: checksum.VerificationProfile{
+				Acceptable: checksum.AlgorithmsByStrength,
+				Selector:   checksum.StrongestChecksumSelector{},
+			},
+			Checksums:        checksum.ChecksumList{checksum.NewHashChecksum(checksum.SHA256, sum[:], sha256.New)},
+			ChecksumRecorder: recorder,
+			RetryPolicy:      retry.NewPolicy(0),
+			Segments:         1,
+			SkipExisting:     mode,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+		Expect(xfer.Execute(context.Background())).To(Succeed())
+	}
+
+	Context("skipping existing files", func() {
+		It("keeps a file with matching checksums", func() {
+			Expect(ioutil.WriteFile(target, origin.content, 0644)).To(Succeed())
+
+			execute(ChecksumSkip)
+			Expect(*origin.opened).To(Equal(0))
+
+			recorded, err := recorder.GetChecksums(context.Background(), "test-file", checksum.AlgorithmList{checksum.SHA256})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorded).To(HaveLen(1))
+		})
+
+		It("replaces a file with different checksums", func() {
+			Expect(ioutil.WriteFile(target, []byte("outdated content"), 0644)).To(Succeed())
+
+			execute(ChecksumSkip)
+			Expect(*origin.opened).To(Equal(1))
+
+			buf, err := ioutil.ReadFile(target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf).To(Equal(origin.content))
+		})
+
+		It("keeps a file with the same size", func() {
+			Expect(ioutil.WriteFile(target, []byte("outdated content"), 0644)).To(Succeed())
+
+			execute(SizeSkip)
+			Expect(*origin.opened).To(Equal(0))
+		})
+
+		It("does not record checksums of unverified files", func() {
+			Expect(ioutil.WriteFile(target, []byte("outdated content"), 0644)).To(Succeed())
+
+			execute(SizeSkip)
+			Expect(*origin.opened).To(Equal(0))
+
+			recorded, err := recorder.GetChecksums(context.Background(), "test-file", checksum.AlgorithmList{checksum.SHA256})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorded).To(BeEmpty())
+		})
+
+		It("replaces a file with a different size", func() {
+			Expect(ioutil.WriteFile(target, []byte("short"), 0644)).To(Succeed())
+
+			execute(SizeSkip)
+			Expect(*origin.opened).To(Equal(1))
+		})
+
+		It("keeps any file", func() {
+			Expect(ioutil.WriteFile(target, []byte("short"), 0644)).To(Succeed())
+
+			execute(AlwaysSkip)
+			Expect(*origin.opened).To(Equal(0))
+		})
+
+		It("downloads a missing file", func() {
+			execute(AlwaysSkip)
+			Expect(*origin.opened).To(Equal(1))
+		})
+
+		It("replaces files without a mode", func() {
+			Expect(ioutil.WriteFile(target, origin.content, 0644)).To(Succeed())
+
+			execute(NoSkip)
+			Expect(*origin.opened).To(Equal(1))
+		})
+	})
+
+	Context("running commands", func() {
+		build := func(commands ...string) error {
+			sum := sha256.Sum256(origin.content)
+
+			xfer, err := BuildTransfer(context.Background(), origin, target, TransferOptions{
+				ChecksumVerification: checksum.VerificationProfile{
+					Acceptable: checksum.AlgorithmsByStrength,
+					Selector:   checksum.StrongestChecksumSelector{},
+				},
+				Checksums:   checksum.ChecksumList{checksum.NewHashChecksum(checksum.SHA256, sum[:], sha256.New)},
+				RetryPolicy: retry.NewPolicy(0),
+				Segments:    1,
+				Exec:        commands,
+				Ref:         "github.com/dpb587/gget@v1.0.0",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+
+			return xfer.Execute(context.Background())
+		}
+
+		It("describes the resource", func() {
+			envPath := filepath.Join(tmpdir, "env")
+
+			Expect(build(`cat "$GGET_LOCAL_PATH" > ` + envPath + `; env | grep ^GGET_ | sort >> ` + envPath)).To(Succeed())
+
+			sum := sha256.Sum256(origin.content)
+
+			buf, err := ioutil.ReadFile(envPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(buf)).To(Equal(`expected content` +
+				`GGET_CHECKSUMS=sha256:` + hex.EncodeToString(sum[:]) + "\n" +
+				`GGET_CHECKSUM_SHA256=` + hex.EncodeToString(sum[:]) + "\n" +
+				`GGET_LOCAL_PATH=` + target + "\n" +
+				`GGET_REF=github.com/dpb587/gget@v1.0.0` + "\n" +
+				`GGET_RESOURCE_NAME=test-file` + "\n",
+			))
+		})
+
+		It("fails with the output of the command", func() {
+			err := build("true", "echo checking >&2; echo unexpected version; exit 3")
+			Expect(err).To(MatchError(ContainSubstring("running command: exit status 3 (unexpected version)")))
+		})
+
+		It("does not support stdout", func() {
+			_, err := BuildTransfer(context.Background(), origin, "-", TransferOptions{Exec: []string{"true"}})
+			Expect(err).To(MatchError("cannot run commands for stdout"))
+		})
+	})
+
+	Context("setting file attributes", func() {
+		It("applies the mode and modification time", func() {
+			modTime := time.Date(2020, 4, 1, 12, 30, 0, 0, time.UTC)
+
+			xfer, err := BuildTransfer(context.Background(), modTimeOrigin{testOrigin: origin, modTime: modTime}, target, TransferOptions{
+				RetryPolicy:     retry.NewPolicy(0),
+				Segments:        1,
+				Mode:            0600,
+				PreserveModTime: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+			Expect(xfer.Execute(context.Background())).To(Succeed())
+
+			fi, err := os.Stat(target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			Expect(fi.ModTime().Equal(modTime)).To(BeTrue())
+		})
+
+		It("does not change cached files", func() {
+			modTime := time.Date(2020, 4, 1, 12, 30, 0, 0, time.UTC)
+			downloadCache := cache.New(filepath.Join(tmpdir, "cache"))
+
+			for _, path := range []string{target, filepath.Join(tmpdir, "result2")} {
+				xfer, err := BuildTransfer(context.Background(), modTimeOrigin{testOrigin: origin, modTime: modTime}, path, TransferOptions{
+					RetryPolicy:     retry.NewPolicy(0),
+					Segments:        1,
+					Cache:           downloadCache,
+					CacheMode:       cache.ReadWriteMode,
+					CacheKey:        "resource-key",
+					Mode:            0444,
+					PreserveModTime: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+				Expect(xfer.Execute(context.Background())).To(Succeed())
+
+				buf, err := ioutil.ReadFile(path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(buf).To(Equal(origin.content))
+
+				fi, err := os.Stat(path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0444)))
+				Expect(fi.ModTime().Equal(modTime)).To(BeTrue())
+			}
+
+			// the second download used the cache
+			Expect(*origin.opened).To(Equal(1))
+
+			entry, found, err := downloadCache.Lookup("resource-key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			fi, err := os.Stat(entry.Path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fi.ModTime().Equal(modTime)).To(BeFalse())
+
+			Expect(os.Chmod(target, 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(target, []byte("changed"), 0644)).To(Succeed())
+
+			buf, err := ioutil.ReadFile(entry.Path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf).To(Equal(origin.content))
+		})
+
+		It("ignores unknown modification times", func() {
+			xfer, err := BuildTransfer(context.Background(), origin, target, TransferOptions{
+				RetryPolicy:     retry.NewPolicy(0),
+				Segments:        1,
+				PreserveModTime: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			xfer.Prepare(mpb.New(mpb.WithOutput(ioutil.Discard)))
+			Expect(xfer.Execute(context.Background())).To(Succeed())
+
+			fi, err := os.Stat(target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(time.Since(fi.ModTime())).To(BeNumerically("<", time.Minute))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package treearchive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/pkg/errors"
+)
+
+var sectionRE = regexp.MustCompile(`^\[\s*submodule\s+"(.+)"\s*\]$`)
+
+// ParseGitmodules returns the repository URLs of submodules, keyed by their path.
+func ParseGitmodules(r io.Reader) (map[string]string, error) {
+	type submodule struct {
+		path string
+		url  string
+	}
+
+	var ordered []*submodule
+	var current *submodule
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		} else if strings.HasPrefix(line, "[") {
+			current = nil
+
+			if sectionRE.MatchString(line) {
+				current = &submodule{}
+				ordered = append(ordered, current)
+			}
+
+			continue
+		} else if current == nil {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "path":
+			current.path = value
+		case "url":
+			current.url = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	res := map[string]string{}
+
+	for _, submodule := range ordered {
+		if submodule.path == "" || submodule.url == "" {
+			continue
+		}
+
+		res[strings.Trim(submodule.path, "/")] = submodule.url
+	}
+
+	return res, nil
+}
+
+// SubmoduleRef converts the URL of a submodule into a ref of its repository. Relative URLs are resolved against the
+// repository of the parent, and repositories on the same server use the service of the parent.
+func SubmoduleRef(parent service.Ref, rawURL, commit string) (service.Ref, error) {
+	var server, repoPath string
+
+	if strings.HasPrefix(rawURL, "./") || strings.HasPrefix(rawURL, "../") {
+		server = parent.Server
+		repoPath = path.Join(parent.Owner, parent.Repository, rawURL)
+	} else if strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return service.Ref{}, errors.Wrap(err, "parsing url")
+		}
+
+		server = u.Hostname()
+		repoPath = u.Path
+	} else if hostPath := strings.SplitN(rawURL, ":", 2); len(hostPath) == 2 { // scp-like (git@host:owner/repo.git)
+		server = hostPath[0][strings.LastIndex(hostPath[0], "@")+1:]
+		repoPath = hostPath[1]
+	} else {
+		return service.Ref{}, fmt.Errorf("unsupported submodule url: %s", rawURL)
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+
+	ref, err := service.ParseRefString(path.Join(server, repoPath))
+	if err != nil {
+		return service.Ref{}, errors.Wrapf(err, "parsing submodule url %s", rawURL)
+	} else if ref.Server == "" {
+		return service.Ref{}, fmt.Errorf("unsupported submodule url: %s", rawURL)
+	}
+
+	if ref.Server == parent.Server {
+		ref.Service = parent.Service
+	}
+
+	ref.Ref = commit
+
+	return ref, nil
+}
@@ -0,0 +1,64 @@
+package treearchive_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dpb587/gget/pkg/service"
+	. "github.com/dpb587/gget/pkg/treearchive"
+)
+
+var _ = Describe("ParseGitmodules", func() {
+	It("parses paths and urls", func() {
+		res, err := ParseGitmodules(strings.NewReader(`# comment
+[submodule "lib"]
+	path = vendor/lib
+	url = https://github.com/example/lib.git
+[core]
+	path = ignored
+[submodule "docs"]
+	url = "../docs.git"
+	path = docs/
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(Equal(map[string]string{
+			"vendor/lib": "https://github.com/example/lib.git",
+			"docs":       "../docs.git",
+		}))
+	})
+})
+
+var _ = Describe("SubmoduleRef", func() {
+	parent := service.Ref{
+		Service:    "gitlab",
+		Server:     "gitlab.example.com",
+		Owner:      "group",
+		Repository: "project",
+		Ref:        "v1.0.0",
+	}
+
+	It("supports https urls", func() {
+		ref, err := SubmoduleRef(parent, "https://github.com/example/lib.git", "abc123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref).To(Equal(service.Ref{Server: "github.com", Owner: "example", Repository: "lib", Ref: "abc123"}))
+	})
+
+	It("supports scp-like urls", func() {
+		ref, err := SubmoduleRef(parent, "git@gitlab.example.com:group/subgroup/lib.git", "abc123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref).To(Equal(service.Ref{Service: "gitlab", Server: "gitlab.example.com", Owner: "group", Repository: "subgroup/lib", Ref: "abc123"}))
+	})
+
+	It("supports relative urls", func() {
+		ref, err := SubmoduleRef(parent, "../lib.git", "abc123")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref).To(Equal(service.Ref{Service: "gitlab", Server: "gitlab.example.com", Owner: "group", Repository: "lib", Ref: "abc123"}))
+	})
+
+	It("fails for local paths", func() {
+		_, err := SubmoduleRef(parent, "/srv/git/lib.git", "abc123")
+		Expect(err).To(HaveOccurred())
+	})
+})
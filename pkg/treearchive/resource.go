@@ -0,0 +1,317 @@
+package treearchive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dpb587/gget/pkg/archive"
+	"github.com/dpb587/gget/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// Resource is an archive which is built from the blobs of a repository tree, including those of submodules, rather
+// than downloaded from the server. Members are within a directory named after the archive (without its extension).
+type Resource struct {
+	origin      service.ResolvedResource
+	format      archive.Format
+	compression archive.Compression
+	refResolver service.RefResolver
+	ref         service.ResolvedRef
+	subtree     string
+}
+
+var _ service.ResolvedResource = &Resource{}
+var _ service.ModTimeResolvedResource = &Resource{}
+
+// NewResource replaces an archive of the server with one built from the ref. A non-empty subtree restricts the
+// archive to a directory of the repository. False is returned if the archive format cannot be built.
+func NewResource(origin service.ResolvedResource, refResolver service.RefResolver, ref service.ResolvedRef, subtree string) (*Resource, bool) {
+	format, compression := archive.DetectFormat(origin.GetName(), nil)
+	if format == archive.UnknownFormat || (compression != archive.NoCompression && compression != archive.GzipCompression) {
+		return nil, false
+	}
+
+	return &Resource{
+		origin:      origin,
+		format:      format,
+		compression: compression,
+		refResolver: refResolver,
+		ref:         ref,
+		subtree:     strings.Trim(subtree, "/"),
+	}, true
+}
+
+func (r *Resource) GetName() string {
+	return r.origin.GetName()
+}
+
+func (r *Resource) GetSize() int64 {
+	return 0
+}
+
+func (r *Resource) GetModTime(ctx context.Context) (time.Time, error) {
+	if mtr, ok := r.origin.(service.ModTimeResolvedResource); ok {
+		return mtr.GetModTime(ctx)
+	}
+
+	return time.Time{}, nil
+}
+
+func (r *Resource) Open(ctx context.Context) (io.ReadCloser, error) {
+	files, err := collect(ctx, r.refResolver, r.ref, r.subtree, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "collecting files")
+	} else if len(files) == 0 && r.subtree != "" {
+		return nil, fmt.Errorf("no files found in path: %s", r.subtree)
+	}
+
+	modTime, err := r.GetModTime(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting mod time")
+	}
+
+	entries, cleanup, err := r.buildEntries(ctx, files, modTime)
+	if err != nil {
+		cleanup()
+
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer cleanup()
+
+		pw.CloseWithError(archive.Write(pw, r.format, r.compression, entries))
+	}()
+
+	return pr, nil
+}
+
+func (r *Resource) buildEntries(ctx context.Context, files []file, modTime time.Time) ([]archive.Entry, func(), error) {
+	var tmpPaths []string
+
+	cleanup := func() {
+		for _, tmpPath := range tmpPaths {
+			os.Remove(tmpPath)
+		}
+	}
+
+	prefix := r.GetName()
+
+	if r.format == archive.ZipFormat {
+		prefix = prefix[0 : len(prefix)-len(".zip")]
+	} else {
+		prefix = prefix[0 : len(prefix)-len(path.Ext(prefix))]
+		prefix = strings.TrimSuffix(prefix, ".tar")
+	}
+
+	var entries []archive.Entry
+
+	for _, f := range files {
+		resource := f.resource
+
+		entry := archive.Entry{
+			Name:    path.Join(prefix, f.path),
+			Mode:    0644,
+			Size:    resource.GetSize(),
+			ModTime: modTime,
+			Open: func() (io.ReadCloser, error) {
+				return resource.Open(ctx)
+			},
+		}
+
+		var treeMode string
+
+		if tmr, ok := resource.(service.TreeModeResolvedResource); ok {
+			treeMode = tmr.GetTreeMode()
+		}
+
+		switch treeMode {
+		case "100755":
+			entry.Mode = 0755
+		case "120000":
+			linkname, err := readAll(ctx, resource)
+			if err != nil {
+				return nil, cleanup, errors.Wrapf(err, "reading symlink %s", f.path)
+			}
+
+			entry.Mode = os.ModeSymlink | 0777
+			entry.Linkname = string(linkname)
+		}
+
+		if entry.Size == 0 && entry.Mode&os.ModeSymlink == 0 && r.format == archive.TarFormat {
+			// tar headers require the size which some servers do not include in trees
+			tmpPath, size, err := spool(ctx, resource)
+			if tmpPath != "" {
+				tmpPaths = append(tmpPaths, tmpPath)
+			}
+
+			if err != nil {
+				return nil, cleanup, errors.Wrapf(err, "downloading %s", f.path)
+			}
+
+			entry.Size = size
+			entry.Open = func() (io.ReadCloser, error) {
+				return os.Open(tmpPath)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, cleanup, nil
+}
+
+type file struct {
+	path     string
+	resource service.ResolvedResource
+}
+
+// collect finds the blobs of a ref within a subtree and recurses into submodules. Paths are relative to the subtree and
+// then prefixed.
+func collect(ctx context.Context, refResolver service.RefResolver, ref service.ResolvedRef, subtree, prefix string) ([]file, error) {
+	blobs, err := ref.ResolveResource(ctx, service.BlobResourceType, service.ResourceName("**"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving blobs of %s", ref.CanonicalRef())
+	}
+
+	var res []file
+	var gitmodules service.ResolvedResource
+
+	for _, blob := range blobs {
+		if blob.GetName() == ".gitmodules" {
+			gitmodules = blob
+		}
+
+		if rel, ok := within(subtree, blob.GetName()); ok && rel != "" {
+			res = append(res, file{
+				path:     path.Join(prefix, rel),
+				resource: blob,
+			})
+		}
+	}
+
+	sr, ok := ref.(service.SubmoduleResolver)
+	if !ok || gitmodules == nil {
+		return sortFiles(res), nil
+	}
+
+	submodules, err := sr.ResolveSubmodules(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving submodules of %s", ref.CanonicalRef())
+	}
+
+	var submoduleURLs map[string]string
+
+	for _, submodule := range submodules {
+		var submoduleSubtree, submodulePrefix string
+
+		if rel, ok := within(subtree, submodule.Path); ok {
+			submodulePrefix = path.Join(prefix, rel)
+		} else if rel, ok := within(submodule.Path, subtree); ok {
+			submoduleSubtree = rel
+			submodulePrefix = prefix
+		} else {
+			continue
+		}
+
+		if submoduleURLs == nil {
+			buf, err := readAll(ctx, gitmodules)
+			if err != nil {
+				return nil, errors.Wrap(err, "reading .gitmodules")
+			}
+
+			submoduleURLs, err = ParseGitmodules(strings.NewReader(string(buf)))
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing .gitmodules")
+			}
+		}
+
+		submoduleURL, found := submoduleURLs[submodule.Path]
+		if !found {
+			return nil, fmt.Errorf("resolving submodule %s: url not found in .gitmodules", submodule.Path)
+		}
+
+		submoduleRef, err := SubmoduleRef(ref.CanonicalRef(), submoduleURL, submodule.Commit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving submodule %s", submodule.Path)
+		}
+
+		resolvedRef, err := refResolver.ResolveRef(ctx, service.LookupRef{Ref: submoduleRef})
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving submodule %s", submodule.Path)
+		}
+
+		submoduleFiles, err := collect(ctx, refResolver, resolvedRef, submoduleSubtree, submodulePrefix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving submodule %s", submodule.Path)
+		}
+
+		res = append(res, submoduleFiles...)
+	}
+
+	return sortFiles(res), nil
+}
+
+// within returns the path relative to dir if it is the same as or within dir. An empty dir contains everything.
+func within(dir, p string) (string, bool) {
+	if dir == "" {
+		return p, true
+	} else if p == dir {
+		return "", true
+	} else if strings.HasPrefix(p, dir+"/") {
+		return p[len(dir)+1:], true
+	}
+
+	return "", false
+}
+
+func sortFiles(files []file) []file {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	return files
+}
+
+func readAll(ctx context.Context, resource service.ResolvedResource) ([]byte, error) {
+	fh, err := resource.Open(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	return ioutil.ReadAll(fh)
+}
+
+func spool(ctx context.Context, resource service.ResolvedResource) (string, int64, error) {
+	fh, err := resource.Open(ctx)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "opening")
+	}
+
+	defer fh.Close()
+
+	tmp, err := ioutil.TempFile("", "gget-treearchive-")
+	if err != nil {
+		return "", 0, errors.Wrap(err, "creating temp file")
+	}
+
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, fh)
+	if err != nil {
+		return tmp.Name(), 0, errors.Wrap(err, "writing temp file")
+	}
+
+	return tmp.Name(), size, nil
+}
@@ -0,0 +1,194 @@
+package treearchive_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/dpb587/gget/pkg/service"
+	. "github.com/dpb587/gget/pkg/treearchive"
+)
+
+type testBlob struct {
+	name    string
+	mode    string
+	content string
+}
+
+func (b testBlob) GetName() string {
+	return b.name
+}
+
+func (b testBlob) GetSize() int64 {
+	// unknown, like some servers
+	return 0
+}
+
+func (b testBlob) GetTreeMode() string {
+	return b.mode
+}
+
+func (b testBlob) Open(ctx context.Context) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(b.content)), nil
+}
+
+type testRef struct {
+	ref        service.Ref
+	blobs      []service.ResolvedResource
+	submodules []service.Submodule
+}
+
+func (r testRef) CanonicalRef() service.Ref {
+	return r.ref
+}
+
+func (r testRef) GetMetadata(ctx context.Context) (service.RefMetadata, error) {
+	return nil, nil
+}
+
+func (r testRef) ResolveResource(ctx context.Context, resourceType service.ResourceType, resource service.ResourceName) ([]service.ResolvedResource, error) {
+	if resourceType == service.ArchiveResourceType {
+		return []service.ResolvedResource{testBlob{name: fmt.Sprintf("%s-%s.tar.gz", r.ref.Repository, r.ref.Ref)}}, nil
+	}
+
+	return r.blobs, nil
+}
+
+func (r testRef) ResolveSubmodules(ctx context.Context) ([]service.Submodule, error) {
+	return r.submodules, nil
+}
+
+type testRefResolver map[string]testRef
+
+func (rr testRefResolver) ResolveRef(ctx context.Context, lookupRef service.LookupRef) (service.ResolvedRef, error) {
+	ref, found := rr[lookupRef.Ref.String()]
+	if !found {
+		return nil, fmt.Errorf("unexpected ref: %s", lookupRef.Ref)
+	}
+
+	return ref, nil
+}
+
+var _ = Describe("Resource", func() {
+	var refResolver testRefResolver
+	var ref testRef
+
+	BeforeEach(func() {
+		ref = testRef{
+			ref: service.Ref{Server: "github.com", Owner: "example", Repository: "app", Ref: "v1"},
+			blobs: []service.ResolvedResource{
+				testBlob{name: ".gitmodules", content: "[submodule \"lib\"]\n\tpath = services/api/lib\n\turl = ../lib.git\n"},
+				testBlob{name: "README.md", content: "app"},
+				testBlob{name: "services/api/main.go", content: "main"},
+				testBlob{name: "services/api/run.sh", mode: "100755", content: "run"},
+				testBlob{name: "services/api/current", mode: "120000", content: "run.sh"},
+			},
+			submodules: []service.Submodule{
+				{Path: "services/api/lib", Commit: "abc123"},
+			},
+		}
+
+		refResolver = testRefResolver{
+			"github.com/example/lib@abc123": testRef{
+				ref: service.Ref{Server: "github.com", Owner: "example", Repository: "lib", Ref: "abc123"},
+				blobs: []service.ResolvedResource{
+					testBlob{name: "lib.go", content: "lib"},
+					testBlob{name: "docs/index.md", content: "docs"},
+				},
+			},
+		}
+	})
+
+	readArchive := func(subtree string) map[string]string {
+		archives, err := ref.ResolveResource(context.Background(), service.ArchiveResourceType, "*")
+		Expect(err).ToNot(HaveOccurred())
+
+		resource, ok := NewResource(archives[0], refResolver, ref, subtree)
+		Expect(ok).To(BeTrue())
+		Expect(resource.GetName()).To(Equal("app-v1.tar.gz"))
+
+		fh, err := resource.Open(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+
+		defer fh.Close()
+
+		gr, err := gzip.NewReader(fh)
+		Expect(err).ToNot(HaveOccurred())
+
+		res := map[string]string{}
+		tr := tar.NewReader(gr)
+
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+
+			if header.Typeflag == tar.TypeSymlink {
+				res[header.Name] = fmt.Sprintf("-> %s", header.Linkname)
+
+				continue
+			}
+
+			buf, err := ioutil.ReadAll(tr)
+			Expect(err).ToNot(HaveOccurred())
+
+			res[header.Name] = fmt.Sprintf("%04o %s", header.Mode, buf)
+		}
+
+		return res
+	}
+
+	It("includes submodules", func() {
+		Expect(readArchive("")).To(Equal(map[string]string{
+			"app-v1/.gitmodules":                    "0644 [submodule \"lib\"]\n\tpath = services/api/lib\n\turl = ../lib.git\n",
+			"app-v1/README.md":                      "0644 app",
+			"app-v1/services/api/current":           "-> run.sh",
+			"app-v1/services/api/lib/docs/index.md": "0644 docs",
+			"app-v1/services/api/lib/lib.go":        "0644 lib",
+			"app-v1/services/api/main.go":           "0644 main",
+			"app-v1/services/api/run.sh":            "0755 run",
+		}))
+	})
+
+	It("restricts to a subtree", func() {
+		Expect(readArchive("services/api")).To(Equal(map[string]string{
+			"app-v1/current":           "-> run.sh",
+			"app-v1/lib/docs/index.md": "0644 docs",
+			"app-v1/lib/lib.go":        "0644 lib",
+			"app-v1/main.go":           "0644 main",
+			"app-v1/run.sh":            "0755 run",
+		}))
+	})
+
+	It("restricts to a subtree of a submodule", func() {
+		Expect(readArchive("services/api/lib/docs/")).To(Equal(map[string]string{
+			"app-v1/index.md": "0644 docs",
+		}))
+	})
+
+	It("fails when a subtree is empty", func() {
+		archives, err := ref.ResolveResource(context.Background(), service.ArchiveResourceType, "*")
+		Expect(err).ToNot(HaveOccurred())
+
+		resource, _ := NewResource(archives[0], refResolver, ref, "missing")
+
+		_, err = resource.Open(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no files found in path: missing"))
+	})
+
+	It("does not support other compression", func() {
+		_, ok := NewResource(testBlob{name: "app-v1.tar.bz2"}, refResolver, ref, "")
+		Expect(ok).To(BeFalse())
+	})
+})